@@ -0,0 +1,68 @@
+package main
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/integrity"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// runCheck implements the `check` command which reports invariant violations of the conference store.
+// It returns the exit code, 1 when violations remain.
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "recompute derived fields, e.g. remaining tickets, before reporting")
+	jsonOutput := flags.Bool("json", false, "print the report as JSON")
+	flags.Parse(args)
+
+	if _, err := config.Load("check", nil); err != nil {
+		fmt.Fprintf(os.Stderr, "check failed: %v\n", err)
+		return 2
+	}
+
+	var report integrity.Report
+	var err error
+	if *fix {
+		report, err = integrity.Fix()
+	} else {
+		report, err = integrity.Check()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check failed: %v\n", err)
+		return 2
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "	")
+		encoder.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if !report.IsHealthy() {
+		return 1
+	}
+	return 0
+}
+
+func printReport(report integrity.Report) {
+	fmt.Printf("checked %v conferences with %v bookings\n", report.Conferences, report.Bookings)
+	for _, confId := range report.Fixed {
+		fmt.Printf("fixed remaining tickets of conference %v\n", confId)
+	}
+	if report.IsHealthy() {
+		fmt.Println("no violations found")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "CODE\tCONFERENCE\tBOOKING\tFIXABLE\tMESSAGE")
+	for _, violation := range report.Violations {
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", violation.Code, violation.ConferenceId, violation.BookingId, violation.Fixable, violation.Message)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"os"
+	"time"
+)
+
+// recordAudit appends a change made with bookingctl to the audit log, the actor is the OS user.
+func recordAudit(action string, entity string, entityId string, confId string, before interface{}, after interface{}) {
+	actor := "bookingctl"
+	if user := os.Getenv("USER"); user != "" {
+		actor += ":" + user
+	}
+
+	entry := model.AuditEntry{
+		Id:           newId(),
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Actor:        actor,
+		ActorRole:    model.RoleAdmin,
+		Action:       action,
+		Entity:       entity,
+		EntityId:     entityId,
+		ConferenceId: confId,
+		Changes:      database.AuditChanges(before, after, "bookings"),
+	}
+
+	if err := database.AppendAuditEntry(entry); err != nil {
+		logging.Default.Error("cannot append audit log entry", "action", action, "entity_id", entityId, "error", err)
+	}
+}
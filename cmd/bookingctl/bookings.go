@@ -0,0 +1,86 @@
+package main
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"flag"
+	"fmt"
+	"io"
+)
+
+func listBookings(args []string) error {
+	flags := flag.NewFlagSet("booking list", flag.ExitOnError)
+	confId := flags.String("conference", "", "id of the conference")
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "conference"); err != nil {
+		return err
+	}
+	if _, err := readConferences(); err != nil {
+		return err
+	}
+	conference, err := database.GetConference(*confId)
+	if err != nil {
+		return err
+	}
+
+	printResult(conference.Bookings, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tCUSTOMER\tTICKETS\tBOOKED AT\tCANCELED")
+		for _, booking := range conference.Bookings {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", booking.Id, booking.CustomerName,
+				booking.TicketsBooked, booking.BookedAt, booking.IsCanceled)
+		}
+	})
+	return nil
+}
+
+func cancelBooking(args []string) error {
+	flags := flag.NewFlagSet("booking cancel", flag.ExitOnError)
+	confId := flags.String("conference", "", "id of the conference")
+	id := flags.String("id", "", "id of the booking")
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "conference", "id"); err != nil {
+		return err
+	}
+	if _, err := readConferences(); err != nil {
+		return err
+	}
+	conference, err := database.GetConference(*confId)
+	if err != nil {
+		return err
+	}
+
+	var booking *model.Booking
+	for index := range conference.Bookings {
+		if conference.Bookings[index].Id == *id {
+			booking = &conference.Bookings[index]
+		}
+	}
+	if booking == nil {
+		return fmt.Errorf("no booking with id %v for conference id %v", *id, *confId)
+	}
+	if booking.IsCanceled {
+		return fmt.Errorf("booking %v is already canceled", *id)
+	}
+
+	event := newEvent(model.EventBookingCanceled, conference.Id)
+	event.BookingId = booking.Id
+	updatedConf, err := database.ApplyEvents(event)
+	if err != nil {
+		return err
+	}
+
+	var canceled model.Booking
+	for _, updated := range updatedConf.Bookings {
+		if updated.Id == booking.Id {
+			canceled = updated
+		}
+	}
+	recordAudit(auditBookingCanceled, "booking", booking.Id, conference.Id, booking, &canceled)
+
+	printResult(canceled, func(w io.Writer) {
+		fmt.Fprintf(w, "canceled booking %v of %v\n", canceled.Id, canceled.CustomerName)
+	})
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/validation"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	auditConferenceCreated  = "conference.created"
+	auditConferenceArchived = "conference.archived"
+	auditBookingCreated     = "booking.created"
+	auditBookingCanceled    = "booking.canceled"
+)
+
+func listConferences(args []string) error {
+	flags := flag.NewFlagSet("conference list", flag.ExitOnError)
+	flags.Parse(args)
+
+	conferences, err := readConferences()
+	if err != nil {
+		return err
+	}
+
+	printResult(conferences, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tTOTAL\tREMAINING\tBOOKINGS\tARCHIVED")
+		for _, conference := range conferences {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", conference.Id, conference.ConferenceName,
+				conference.TotalTickets, conference.RemainingTickets, len(conference.Bookings), conference.IsArchived)
+		}
+	})
+	return nil
+}
+
+func createConference(args []string) error {
+	flags := flag.NewFlagSet("conference create", flag.ExitOnError)
+	name := flags.String("name", "", "name of the conference")
+	tickets := flags.Uint("tickets", 0, "total number of tickets")
+	flags.Parse(args)
+
+	conferences, err := readConferences()
+	if err != nil {
+		return err
+	}
+
+	trimmedName := strings.TrimSpace(*name)
+	req := &model.ConferenceRequest{ConferenceName: &trimmedName, TotalTickets: tickets}
+	if validationErrs := validation.Conference(req, nil, conferences, false); len(validationErrs) > 0 {
+		return validationErrs
+	}
+
+	event := newEvent(model.EventConferenceCreated, newId())
+	event.ConferenceName = trimmedName
+	event.TotalTickets = *tickets
+	conference, err := database.ApplyEvents(event)
+	if err != nil {
+		return err
+	}
+	recordAudit(auditConferenceCreated, "conference", conference.Id, conference.Id, nil, &conference)
+
+	printResult(conference, func(w io.Writer) {
+		fmt.Fprintf(w, "created conference %v with id %v\n", conference.ConferenceName, conference.Id)
+	})
+	return nil
+}
+
+func archiveConference(args []string) error {
+	flags := flag.NewFlagSet("conference archive", flag.ExitOnError)
+	id := flags.String("id", "", "id of the conference")
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "id"); err != nil {
+		return err
+	}
+	if _, err := readConferences(); err != nil {
+		return err
+	}
+	conference, err := database.GetConference(*id)
+	if err != nil {
+		return err
+	}
+	if conference.IsArchived {
+		return fmt.Errorf("conference %v is already archived", *id)
+	}
+
+	archived, err := database.ApplyEvents(newEvent(model.EventConferenceArchived, conference.Id))
+	if err != nil {
+		return err
+	}
+	recordAudit(auditConferenceArchived, "conference", conference.Id, conference.Id, &conference, &archived)
+
+	printResult(archived, func(w io.Writer) {
+		fmt.Fprintf(w, "archived conference %v\n", archived.Id)
+	})
+	return nil
+}
+
+// readConferences brings the ledger up to date before the conferences are read.
+func readConferences() ([]model.Conference, error) {
+	if err := database.InitLedger(); err != nil {
+		return nil, err
+	}
+	return database.ReadLocalDB()
+}
+
+func newEvent(eventType string, confId string) model.Event {
+	return model.Event{
+		Type:         eventType,
+		OccurredAt:   time.Now().Format(time.RFC3339),
+		ConferenceId: confId,
+	}
+}
+
+func newId() string {
+	newUuid, _ := uuid.NewRandom()
+	return strings.Replace(newUuid.String(), "-", "", -1)
+}
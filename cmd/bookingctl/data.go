@@ -0,0 +1,191 @@
+package main
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+	"booking-webapp/transfer"
+	"booking-webapp/validation"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type importResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+const formatJSON = "json"
+
+// exportData writes all conferences with their bookings in the format of the conferences store,
+// or as CSV or JSON Lines rows.
+func exportData(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	file := flags.String("file", "", "file to write the export to, stdout if empty")
+	format := flags.String("format", formatJSON, "json, csv or jsonl")
+	flags.Parse(args)
+
+	if *format != formatJSON && !transfer.IsFormat(*format) {
+		return fmt.Errorf("unknown format %v, use json, csv or jsonl", *format)
+	}
+
+	conferences, err := readConferences()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *file != "" {
+		out, err = os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "	")
+		err = encoder.Encode(conferences)
+	} else {
+		err = transfer.Export(out, *format, conferences)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *file != "" {
+		printResult(conferences, func(w io.Writer) {
+			fmt.Fprintf(w, "exported %v conferences to %v\n", len(conferences), *file)
+		})
+	}
+	return nil
+}
+
+// importData adds the conferences of an export through the ledger. Conferences whose id
+// already exists are skipped, so an import can be repeated after a partial failure.
+// CSV and JSON Lines rows are imported with transfer.Import instead.
+func importData(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "file with the exported conferences, - for stdin")
+	format := flags.String("format", formatJSON, "json, csv or jsonl")
+	dryRun := flags.Bool("dry-run", false, "only validate the rows, csv and jsonl only")
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "file"); err != nil {
+		return err
+	}
+	if *format != formatJSON && !transfer.IsFormat(*format) {
+		return fmt.Errorf("unknown format %v, use json, csv or jsonl", *format)
+	}
+	if *dryRun && *format == formatJSON {
+		return fmt.Errorf("--dry-run is supported for csv and jsonl only")
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		var err error
+		in, err = os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	if *format != formatJSON {
+		return importRows(in, *format, *dryRun)
+	}
+
+	imported := []model.Conference{}
+	if err := json.NewDecoder(in).Decode(&imported); err != nil {
+		return fmt.Errorf("cannot parse %v: %v", *file, err)
+	}
+
+	conferences, err := readConferences()
+	if err != nil {
+		return err
+	}
+	existingIds := map[string]bool{}
+	for _, conference := range conferences {
+		existingIds[conference.Id] = true
+	}
+
+	result := importResult{Imported: []string{}, Skipped: []string{}}
+	importedAt := time.Now().Format(time.RFC3339)
+	for _, conference := range imported {
+		if conference.Id == "" {
+			conference.Id = newId()
+		} else if existingIds[conference.Id] {
+			result.Skipped = append(result.Skipped, conference.Id)
+			continue
+		}
+
+		req := &model.ConferenceRequest{ConferenceName: &conference.ConferenceName, TotalTickets: &conference.TotalTickets}
+		if validationErrs := validation.Conference(req, nil, conferences, false); len(validationErrs) > 0 {
+			return fmt.Errorf("conference %v: %v", conference.Id, validationErrs)
+		}
+
+		created, err := database.ApplyEvents(ledger.Seed(conference, importedAt)...)
+		if err != nil {
+			return fmt.Errorf("conference %v: %v", conference.Id, err)
+		}
+		recordAudit(auditConferenceCreated, "conference", created.Id, created.Id, nil, &created)
+
+		conferences = append(conferences, created)
+		existingIds[created.Id] = true
+		result.Imported = append(result.Imported, created.Id)
+	}
+
+	printResult(result, func(w io.Writer) {
+		fmt.Fprintf(w, "imported %v conferences, skipped %v existing\n", len(result.Imported), len(result.Skipped))
+	})
+	return nil
+}
+
+func importRows(in io.Reader, format string, dryRun bool) error {
+	if _, err := readConferences(); err != nil {
+		return err
+	}
+
+	report, err := transfer.Import(in, format, transfer.Options{DryRun: dryRun})
+	for _, imported := range report.Conferences {
+		if imported.After.Id == "" {
+			continue
+		}
+		if imported.Created {
+			recordAudit(auditConferenceCreated, "conference", imported.Id, imported.Id, nil, &imported.After)
+		}
+		for _, bookingId := range imported.Bookings {
+			for _, booking := range imported.After.Bookings {
+				if booking.Id == bookingId {
+					recordAudit(auditBookingCreated, "booking", bookingId, imported.Id, nil, &booking)
+				}
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	printResult(report, func(w io.Writer) {
+		for _, rowErr := range report.Errors {
+			fmt.Fprintf(w, "line %v\t%v\t%v\n", rowErr.Line, rowErr.Field, rowErr.Message)
+		}
+		switch {
+		case len(report.Errors) > 0:
+			fmt.Fprintf(w, "%v errors found in %v rows, nothing was imported\n", len(report.Errors), report.Rows)
+		case report.DryRun:
+			fmt.Fprintf(w, "dry run, all %v rows are valid\n", report.Rows)
+		default:
+			fmt.Fprintf(w, "imported %v rows into %v conferences\n", report.Rows, len(report.Conferences))
+		}
+	})
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("import has %v invalid rows", len(report.Errors))
+	}
+	return nil
+}
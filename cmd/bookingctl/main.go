@@ -0,0 +1,127 @@
+// Command bookingctl administers users, conferences and bookings of the booking service.
+// It works on the same database as the server, see the README for the available commands.
+package main
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/validation"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `usage: bookingctl [-o human|json] <command> [flags]
+
+commands:
+  user create --login LOGIN [--role ROLE] (--password PASSWORD | --password-stdin)
+  user reset-password --login LOGIN (--password PASSWORD | --password-stdin)
+  conference list
+  conference create --name NAME --tickets TICKETS
+  conference archive --id ID
+  booking list --conference ID
+  booking cancel --conference ID --id ID
+  export [--file FILE] [--format json|csv|jsonl]
+  import --file FILE [--format json|csv|jsonl] [--dry-run]
+`
+
+// outputFormat is either human or json, it is set by the global -o flag.
+var outputFormat = "human"
+
+var commands = map[string]map[string]func(args []string) error{
+	"user": {
+		"create":         createUser,
+		"reset-password": resetPassword,
+	},
+	"conference": {
+		"list":    listConferences,
+		"create":  createConference,
+		"archive": archiveConference,
+	},
+	"booking": {
+		"list":   listBookings,
+		"cancel": cancelBooking,
+	},
+}
+
+func main() {
+	flags := flag.NewFlagSet("bookingctl", flag.ExitOnError)
+	flags.StringVar(&outputFormat, "o", "human", "output format, human or json")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flags.Parse(os.Args[1:])
+
+	if outputFormat != "human" && outputFormat != "json" {
+		fail(fmt.Errorf("unknown output format %v, use human or json", outputFormat))
+	}
+
+	if _, err := config.Load("bookingctl", nil); err != nil {
+		fail(err)
+	}
+	if err := validation.LoadRules(); err != nil {
+		fail(err)
+	}
+
+	args := flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "export":
+		err = exportData(args[1:])
+	case "import":
+		err = importData(args[1:])
+	default:
+		actions, ok := commands[args[0]]
+		if !ok || len(args) < 2 || actions[args[1]] == nil {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = actions[args[1]](args[2:])
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "bookingctl: %v\n", err)
+	os.Exit(1)
+}
+
+// printResult writes value as indented JSON in json output mode and calls human otherwise.
+func printResult(value interface{}, human func(w io.Writer)) {
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "	")
+		encoder.Encode(value)
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	human(writer)
+	writer.Flush()
+}
+
+// requireFlags returns an error naming all of the flags which were left empty, in the given order.
+func requireFlags(flags *flag.FlagSet, names ...string) error {
+	missing := []string{}
+	for _, name := range names {
+		if flags.Lookup(name).Value.String() == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%v is required", missing[0])
+	}
+	return fmt.Errorf("%v are required", strings.Join(missing, ", "))
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireFlagsReportsAllMissingFlagsInOrder(t *testing.T) {
+	flags := flag.NewFlagSet("booking cancel", flag.ContinueOnError)
+	flags.String("conference", "", "id of the conference")
+	flags.String("id", "", "id of the booking")
+
+	assert.EqualError(t, requireFlags(flags, "conference", "id"), "--conference, --id are required")
+	assert.NoError(t, flags.Parse([]string{"--id", "booking1"}))
+	assert.EqualError(t, requireFlags(flags, "conference", "id"), "--conference is required")
+	assert.NoError(t, flags.Parse([]string{"--conference", "conf1"}))
+	assert.NoError(t, requireFlags(flags, "conference", "id"))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type userResult struct {
+	Login string `json:"login"`
+	Role  string `json:"role,omitempty"`
+}
+
+func createUser(args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	login := flags.String("login", "", "login of the new user")
+	role := flags.String("role", model.RoleUser, "role of the new user, one of "+strings.Join(model.UserRoles, ", "))
+	password := passwordFlags(flags)
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "login"); err != nil {
+		return err
+	}
+	if !isUserRole(*role) {
+		return fmt.Errorf("unknown role %v, use one of %v", *role, strings.Join(model.UserRoles, ", "))
+	}
+	hash, err := password()
+	if err != nil {
+		return err
+	}
+
+	if err := connectUsers(); err != nil {
+		return err
+	}
+	if err := database.CreateUser(*login, hash, *role); err != nil {
+		return err
+	}
+
+	printResult(userResult{Login: *login, Role: *role}, func(w io.Writer) {
+		fmt.Fprintf(w, "created user %v with role %v\n", *login, *role)
+	})
+	return nil
+}
+
+func resetPassword(args []string) error {
+	flags := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+	login := flags.String("login", "", "login of the user")
+	password := passwordFlags(flags)
+	flags.Parse(args)
+
+	if err := requireFlags(flags, "login"); err != nil {
+		return err
+	}
+	hash, err := password()
+	if err != nil {
+		return err
+	}
+
+	if err := connectUsers(); err != nil {
+		return err
+	}
+	if err := database.SetUserPassword(*login, hash); err != nil {
+		return err
+	}
+
+	printResult(userResult{Login: *login}, func(w io.Writer) {
+		fmt.Fprintf(w, "password of user %v was reset\n", *login)
+	})
+	return nil
+}
+
+// passwordFlags registers --password and --password-stdin, the returned function
+// reads the password from either of them and hashes it.
+func passwordFlags(flags *flag.FlagSet) func() (string, error) {
+	password := flags.String("password", "", "password of the user, prefer --password-stdin to keep it out of the shell history")
+	fromStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+
+	return func() (string, error) {
+		if *fromStdin {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", fmt.Errorf("cannot read password from stdin: %v", err)
+			}
+			*password = strings.TrimRight(line, "\r\n")
+		}
+		if *password == "" {
+			return "", errors.New("password is required, use --password or --password-stdin")
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("cannot hash password: %v", err)
+		}
+		return string(hash), nil
+	}
+}
+
+func isUserRole(role string) bool {
+	for _, userRole := range model.UserRoles {
+		if role == userRole {
+			return true
+		}
+	}
+	return false
+}
+
+func connectUsers() error {
+	var err error
+	database.UsersCollection, err = database.DBInit("users")
+	return err
+}
@@ -2,15 +2,44 @@ package config
 
 import (
 	"fmt"
-	"os"
 )
 
-const LOCAL_DB_PATH string = "./database/conferences.json"
+// The paths of the local files and the Mongo database of Current, see Storage and Mongo for the defaults.
+var LOCAL_DB_PATH string
+var EVENT_LOG_PATH string
+var PROJECTION_POSITION_PATH string
+var AUDIT_LOG_PATH string
+var IDEMPOTENCY_DB_PATH string
+var OUTBOX_DB_PATH string
+var WEBHOOKS_DB_PATH string
+var WEBHOOK_DELIVERY_LOG_PATH string
+var JOBS_DB_PATH string
+var MONGODB_DATABASE string
 
+// StoragePaths returns the paths of all local files of the service, e.g. to move them to a temp dir in tests.
+func StoragePaths() []*string {
+	return []*string{
+		&LOCAL_DB_PATH, &EVENT_LOG_PATH, &PROJECTION_POSITION_PATH, &AUDIT_LOG_PATH, &IDEMPOTENCY_DB_PATH,
+		&OUTBOX_DB_PATH, &WEBHOOKS_DB_PATH, &WEBHOOK_DELIVERY_LOG_PATH, &JOBS_DB_PATH,
+	}
+}
+
+// GetSecret returns the setting from the flags, the environment or the config file, see Lookup.
+// Unlike GetEnvOrDefault it fails when the setting is missing.
 func GetSecret(key string) (string, error) {
-	val, exist := os.LookupEnv(key)
+	val, exist := Lookup(key)
 	if exist {
 		return val, nil
 	}
-	return "", fmt.Errorf("no env variable with key %v", key)
+	return "", fmt.Errorf("no setting with key %v in flags, environment or config file", key)
+}
+
+// GetEnvOrDefault returns the setting from the flags, the environment or the config file,
+// defaultVal when it is missing or empty.
+func GetEnvOrDefault(key string, defaultVal string) string {
+	val, exist := Lookup(key)
+	if exist && val != "" {
+		return val
+	}
+	return defaultVal
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// The settings are looked up in layers, the first layer which has a setting wins:
+// command line flags, the environment, the config file. Defaults are given by the callers.
+var (
+	layersMutex sync.RWMutex
+	flagValues  = map[string]string{}
+	fileValues  = map[string]string{}
+)
+
+// Lookup returns the setting with the key from the flags, the environment or the config file.
+// In every layer a KEY_FILE setting names a file holding the value, e.g. a Docker secret in
+// /run/secrets, it is used when KEY itself is not set. Surrounding whitespace of the file is dropped.
+func Lookup(key string) (string, bool) {
+	layersMutex.RLock()
+	defer layersMutex.RUnlock()
+
+	for _, lookup := range []func(string) (string, bool){lookupMap(flagValues), os.LookupEnv, lookupMap(fileValues)} {
+		if val, exist := lookup(key); exist {
+			return val, true
+		}
+		if path, exist := lookup(key + "_FILE"); exist && path != "" {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				// reported by Validate
+				continue
+			}
+			return strings.TrimSpace(string(content)), true
+		}
+	}
+	return "", false
+}
+
+func lookupMap(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, exist := values[key]
+		return val, exist
+	}
+}
+
+// setLayers replaces the flag and config file layers.
+func setLayers(flags map[string]string, file map[string]string) {
+	layersMutex.Lock()
+	defer layersMutex.Unlock()
+	flagValues = flags
+	fileValues = file
+}
+
+// normalizeKey turns a flag or config file key like server.read-timeout into SERVER_READ_TIMEOUT.
+func normalizeKey(key string) string {
+	key = strings.TrimSpace(key)
+	key = strings.NewReplacer(".", "_", "-", "_").Replace(key)
+	return strings.ToUpper(key)
+}
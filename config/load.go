@@ -0,0 +1,275 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed configuration of the service.
+type Config struct {
+	Server      Server
+	Mongo       Mongo
+	Storage     Storage
+	Outbox      Outbox
+	Scheduler   Scheduler
+	Feed        Feed
+	Stream      Stream
+	Webhooks    Webhooks
+	Tickets     Tickets
+	Idempotency Idempotency
+	Health      Health
+	API         API
+	Notify      Notify
+	LogLevel    string
+}
+
+// Current is the configuration of the last Load, the packages of the service read their settings from it.
+// Before Load it holds the settings of the environment, invalid ones keep their defaults.
+var Current Config
+
+func init() {
+	loaded, _ := loadConfig()
+	use(loaded)
+}
+
+// Mongo holds the database settings, MONGODB_DATABASE (booking-service). The connection string
+// is a secret and is read by database.DBInit.
+type Mongo struct {
+	Database string
+}
+
+// Storage holds the paths of the local files of the service, LOCAL_DB_PATH, EVENT_LOG_PATH and so on,
+// by default in ./database.
+type Storage struct {
+	LocalDBPath            string
+	EventLogPath           string
+	ProjectionPositionPath string
+	AuditLogPath           string
+	IdempotencyDBPath      string
+	OutboxDBPath           string
+	WebhooksDBPath         string
+	WebhookDeliveryLogPath string
+	JobsDBPath             string
+}
+
+// Errors are all the problems found in the configuration.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e, "\n  ")
+}
+
+// Load reads the configuration from the flags in args, the environment and the config file, in this
+// order of precedence, and validates it. The config file is given by the -config flag or CONFIG_FILE.
+// required are the settings the program cannot run without. All problems are reported at once as Errors,
+// flag.ErrHelp is returned when -h was given. Current and the package variables, e.g. LOCAL_DB_PATH, are updated.
+func Load(name string, args []string, required ...string) (Config, error) {
+	flags := map[string]string{}
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.Func("config", "YAML config file, overrides CONFIG_FILE", func(path string) error {
+		flags["CONFIG_FILE"] = path
+		return nil
+	})
+	flagSet.Func("addr", "address to listen on, overrides SERVER_ADDR", func(addr string) error {
+		flags["SERVER_ADDR"] = addr
+		return nil
+	})
+	flagSet.Func("set", "KEY=VALUE setting, e.g. -set server.read_timeout=10s, can be repeated", func(setting string) error {
+		key, val, found := strings.Cut(setting, "=")
+		if !found || normalizeKey(key) == "" {
+			return fmt.Errorf("%q is not KEY=VALUE", setting)
+		}
+		flags[normalizeKey(key)] = val
+		return nil
+	})
+	if err := flagSet.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if flagSet.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %v", strings.Join(flagSet.Args(), " "))
+	}
+
+	var problems Errors
+	setLayers(flags, map[string]string{})
+	file := map[string]string{}
+	if path := GetEnvOrDefault("CONFIG_FILE", ""); path != "" {
+		var err error
+		file, err = readConfigFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	setLayers(flags, file)
+
+	problems = append(problems, validate(flags, file, required)...)
+
+	// settings which validate reported already are not reported again
+	reported := map[string]bool{}
+	for _, problem := range problems {
+		key, _, _ := strings.Cut(problem, ":")
+		reported[key] = true
+	}
+	loaded, loadProblems := loadConfig()
+	for _, problem := range loadProblems {
+		if key, _, _ := strings.Cut(problem, ":"); !reported[key] {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return Config{}, problems
+	}
+
+	use(loaded)
+	return loaded, nil
+}
+
+// loadConfig reads the typed configuration from the settings. Invalid settings keep their default
+// and are reported, so all problems are found at once.
+func loadConfig() (Config, Errors) {
+	loaded := Config{
+		LogLevel: GetEnvOrDefault("LOG_LEVEL", "info"),
+		Mongo:    Mongo{Database: GetEnvOrDefault("MONGODB_DATABASE", "booking-service")},
+	}
+	var problems Errors
+	for _, load := range []func(*Config) Errors{loadServer, loadStorage, loadServices, loadNotify} {
+		problems = append(problems, load(&loaded)...)
+	}
+	sort.Strings(problems)
+	return loaded, problems
+}
+
+// loadStorage reads the paths of the local files, see Storage.
+func loadStorage(loaded *Config) Errors {
+	paths := []struct {
+		key          string
+		defaultValue string
+		value        *string
+	}{
+		{"LOCAL_DB_PATH", "./database/conferences.json", &loaded.Storage.LocalDBPath},
+		{"EVENT_LOG_PATH", "./database/events.jsonl", &loaded.Storage.EventLogPath},
+		{"PROJECTION_POSITION_PATH", "./database/projection_position", &loaded.Storage.ProjectionPositionPath},
+		{"AUDIT_LOG_PATH", "./database/audit.jsonl", &loaded.Storage.AuditLogPath},
+		{"IDEMPOTENCY_DB_PATH", "./database/idempotency.json", &loaded.Storage.IdempotencyDBPath},
+		{"OUTBOX_DB_PATH", "./database/outbox.json", &loaded.Storage.OutboxDBPath},
+		{"WEBHOOKS_DB_PATH", "./database/webhooks.json", &loaded.Storage.WebhooksDBPath},
+		{"WEBHOOK_DELIVERY_LOG_PATH", "./database/webhook_deliveries.jsonl", &loaded.Storage.WebhookDeliveryLogPath},
+		{"JOBS_DB_PATH", "./database/jobs.json", &loaded.Storage.JobsDBPath},
+	}
+	for _, path := range paths {
+		*path.value = GetEnvOrDefault(path.key, path.defaultValue)
+	}
+	return nil
+}
+
+// use makes loaded the current configuration and updates the package variables, e.g. LOCAL_DB_PATH.
+func use(loaded Config) {
+	MONGODB_DATABASE = loaded.Mongo.Database
+	LOCAL_DB_PATH = loaded.Storage.LocalDBPath
+	EVENT_LOG_PATH = loaded.Storage.EventLogPath
+	PROJECTION_POSITION_PATH = loaded.Storage.ProjectionPositionPath
+	AUDIT_LOG_PATH = loaded.Storage.AuditLogPath
+	IDEMPOTENCY_DB_PATH = loaded.Storage.IdempotencyDBPath
+	OUTBOX_DB_PATH = loaded.Storage.OutboxDBPath
+	WEBHOOKS_DB_PATH = loaded.Storage.WebhooksDBPath
+	WEBHOOK_DELIVERY_LOG_PATH = loaded.Storage.WebhookDeliveryLogPath
+	JOBS_DB_PATH = loaded.Storage.JobsDBPath
+	Current = loaded
+}
+
+// readConfigFile reads a YAML file, nested keys are joined, so server: {addr: ":8080"} is SERVER_ADDR.
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %v: %v", path, err)
+	}
+
+	values := map[string]string{}
+	var problems []string
+	var flatten func(prefix string, node map[string]interface{})
+	flatten = func(prefix string, node map[string]interface{}) {
+		for key, val := range node {
+			key = normalizeKey(prefix + key)
+			switch val := val.(type) {
+			case map[string]interface{}:
+				flatten(key+"_", val)
+			case []interface{}:
+				problems = append(problems, fmt.Sprintf("%v: lists are not supported", key))
+			case nil:
+				values[key] = ""
+			case time.Time:
+				// unquoted dates like 2027-06-30 are timestamps in YAML
+				values[key] = val.Format(time.RFC3339)
+				if val.Equal(val.Truncate(24 * time.Hour)) {
+					values[key] = val.Format("2006-01-02")
+				}
+			default:
+				values[key] = fmt.Sprint(val)
+			}
+		}
+	}
+	flatten("", document)
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return values, fmt.Errorf("config file %v: %v", path, strings.Join(problems, ", "))
+	}
+	return values, nil
+}
+
+// validate checks the settings of all layers: unknown keys in the flags and the config file,
+// unreadable KEY_FILE files, invalid values and missing required settings.
+func validate(flags map[string]string, file map[string]string, required []string) Errors {
+	var problems Errors
+	for layer, values := range map[string]map[string]string{"flag": flags, "config file": file} {
+		for key := range values {
+			if !known(key) {
+				problems = append(problems, fmt.Sprintf("%v: unknown %v setting", key, layer))
+			}
+		}
+	}
+
+	for key, kind := range settings {
+		for _, path := range filePaths(key, flags, file) {
+			if _, err := os.ReadFile(path); err != nil {
+				problems = append(problems, fmt.Sprintf("%v_FILE: cannot read %v", key, path))
+			}
+		}
+		val, exist := Lookup(key)
+		if !exist || val == "" {
+			continue
+		}
+		if err := kind.check(val); err != nil {
+			problems = append(problems, fmt.Sprintf("%v: %v", key, err))
+		}
+	}
+
+	for _, key := range required {
+		if val, exist := Lookup(key); !exist || val == "" {
+			problems = append(problems, fmt.Sprintf("%v: required, set it or %v_FILE", key, key))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// filePaths returns the KEY_FILE settings of all layers.
+func filePaths(key string, flags map[string]string, file map[string]string) []string {
+	var paths []string
+	for _, lookup := range []func(string) (string, bool){lookupMap(flags), os.LookupEnv, lookupMap(file)} {
+		if path, exist := lookup(key + "_FILE"); exist && path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func resetLayers(t *testing.T) {
+	prevCurrent := Current
+	t.Cleanup(func() {
+		setLayers(map[string]string{}, map[string]string{})
+		use(prevCurrent)
+	})
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	resetLayers(t)
+	secret := writeFile(t, "sign", "file-secret\n")
+	configFile := writeFile(t, "config.yaml", `
+server:
+  addr: ":8080"
+  read_timeout: 10s
+  body_limit: 1024
+mongodb:
+  database: bookings
+  connstring_file: `+secret+`
+local_db_path: /data/conferences.json
+`)
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("SERVER_READ_TIMEOUT", "20s")
+	t.Setenv("SIGN", "")
+	os.Unsetenv("SIGN")
+	t.Setenv("SIGN_FILE", secret)
+
+	loaded, err := Load("test", []string{"-addr", ":9090", "-set", "server.idle-timeout=1m"}, "SIGN", "MONGODB_CONNSTRING")
+	assert.NoError(t, err)
+
+	// flags over env over file over defaults
+	assert.Equal(t, ":9090", loaded.Server.Addr)
+	assert.Equal(t, 20*time.Second, loaded.Server.ReadTimeout)
+	assert.Equal(t, time.Minute, loaded.Server.IdleTimeout)
+	assert.Equal(t, 1024, loaded.Server.BodyLimit)
+	assert.Equal(t, 30*time.Second, loaded.Server.ShutdownTimeout)
+	assert.Equal(t, "bookings", loaded.Mongo.Database)
+	assert.Equal(t, "/data/conferences.json", LOCAL_DB_PATH)
+
+	// secrets from files, of the env and of the config file
+	sign, err := GetSecret("SIGN")
+	assert.NoError(t, err)
+	assert.Equal(t, "file-secret", sign)
+	connString, err := GetSecret("MONGODB_CONNSTRING")
+	assert.NoError(t, err)
+	assert.Equal(t, "file-secret", connString)
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	resetLayers(t)
+	configFile := writeFile(t, "config.yaml", `
+server:
+  read_timeout: soon
+  port: 80
+notify_transport: pigeon
+`)
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("FEED_BUFFER", "-1")
+	t.Setenv("SIGN", "")
+	t.Setenv("MONGODB_CONNSTRING_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := Load("test", []string{"-set", "unknown=1"}, "SIGN", "MONGODB_CONNSTRING")
+	var problems Errors
+	if assert.True(t, errors.As(err, &problems)) {
+		assert.Equal(t, Errors{
+			"FEED_BUFFER: \"-1\" is negative",
+			"MONGODB_CONNSTRING: required, set it or MONGODB_CONNSTRING_FILE",
+			"MONGODB_CONNSTRING_FILE: cannot read " + os.Getenv("MONGODB_CONNSTRING_FILE"),
+			"NOTIFY_TRANSPORT: \"pigeon\" is not one of smtp, log, none",
+			"SERVER_PORT: unknown config file setting",
+			"SERVER_READ_TIMEOUT: \"soon\" is not a duration like 30s or 5m",
+			"SIGN: required, set it or SIGN_FILE",
+			"UNKNOWN: unknown flag setting",
+		}, problems)
+	}
+}
+
+func TestLoadServices(t *testing.T) {
+	resetLayers(t)
+	configFile := writeFile(t, "config.yaml", `
+feed:
+  buffer: 16
+validation:
+  max_tickets_per_booking: 20
+api:
+  unversioned_sunset: 2028-01-31
+smtp:
+  host: mail.example.com
+  port: 2525
+`)
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("OUTBOX_POLL_INTERVAL", "5s")
+	t.Setenv("SMTP_PORT", "587")
+	t.Setenv("SIGN", "test-sign")
+
+	loaded, err := Load("test", nil, "SIGN")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, loaded.Outbox.PollInterval)
+	assert.Equal(t, 4, loaded.Outbox.Concurrency)
+	assert.Equal(t, 16, loaded.Feed.Buffer)
+	assert.Equal(t, 15*time.Second, loaded.Stream.HeartbeatInterval)
+	assert.Equal(t, 8760*time.Hour, loaded.Tickets.Validity)
+	assert.Equal(t, "@hourly", loaded.Scheduler.CleanupSchedule)
+	assert.Equal(t, time.Date(2028, 1, 31, 0, 0, 0, 0, time.UTC), loaded.API.UnversionedSunset)
+	assert.Equal(t, Notify{Transport: "smtp", SMTP: SMTP{Host: "mail.example.com", Port: 587, From: "bookings@localhost"}}, loaded.Notify)
+	assert.Equal(t, loaded, Current)
+}
+
+func TestLoadRejectsInvalidServiceSettings(t *testing.T) {
+	resetLayers(t)
+	configFile := writeFile(t, "config.yaml", `
+validation:
+  foo: 1
+`)
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("SIGN", "test-sign")
+	t.Setenv("VALIDATION_NAME_CHARS", "(")
+
+	_, err := Load("test", nil, "SIGN")
+	var problems Errors
+	if assert.True(t, errors.As(err, &problems)) {
+		if assert.Len(t, problems, 2) {
+			assert.Equal(t, "VALIDATION_FOO: unknown config file setting", problems[0])
+			assert.Contains(t, problems[1], "VALIDATION_NAME_CHARS: \"(\" is not a regular expression")
+		}
+	}
+
+	os.Unsetenv("CONFIG_FILE")
+	t.Setenv("VALIDATION_NAME_CHARS", "")
+	t.Setenv("OUTBOX_CONCURRENCY", "0")
+	t.Setenv("WEBHOOK_TIMEOUT", "0s")
+	t.Setenv("SERVER_BODY_LIMIT", "0")
+	t.Setenv("SMTP_PORT", "0")
+	t.Setenv("LOG_LEVEL", "loud")
+	_, err = Load("test", nil, "SIGN")
+	// the problems of validate and of the typed settings are reported together
+	if assert.True(t, errors.As(err, &problems)) {
+		assert.Equal(t, Errors{
+			"LOG_LEVEL: \"loud\" is not one of debug, info, warn, error",
+			"OUTBOX_CONCURRENCY: \"0\" is not a positive number",
+			"SERVER_BODY_LIMIT: \"0\" is not a positive number",
+			"SMTP_PORT: \"0\" is not a positive number",
+			"WEBHOOK_TIMEOUT: \"0s\" is not a positive duration",
+		}, problems)
+	}
+}
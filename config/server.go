@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Server holds the settings of the HTTP server and its shutdown.
+type Server struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	BodyLimit       int
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
+}
+
+// loadServer reads SERVER_ADDR (:80), SERVER_READ_TIMEOUT (30s), SERVER_WRITE_TIMEOUT (0s, no limit, so the
+// event streams stay open), SERVER_IDLE_TIMEOUT (120s), SERVER_BODY_LIMIT in bytes (4 MiB), SHUTDOWN_TIMEOUT (30s)
+// and SHUTDOWN_DRAIN_DELAY (5s), how long the server keeps serving as not ready before it shuts down.
+func loadServer(loaded *Config) Errors {
+	var problems Errors
+	loaded.Server.Addr = GetEnvOrDefault("SERVER_ADDR", ":80")
+
+	durations := []struct {
+		key          string
+		defaultValue string
+		value        *time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", "30s", &loaded.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", "0s", &loaded.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", "120s", &loaded.Server.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", "30s", &loaded.Server.ShutdownTimeout},
+		{"SHUTDOWN_DRAIN_DELAY", "5s", &loaded.Server.DrainDelay},
+	}
+	for _, duration := range durations {
+		*duration.value, _ = time.ParseDuration(duration.defaultValue)
+		value := GetEnvOrDefault(duration.key, duration.defaultValue)
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			problems = append(problems, fmt.Sprintf("%v: %q is not a duration like 30s or 5m", duration.key, value))
+			continue
+		}
+		*duration.value = parsed
+	}
+
+	loaded.Server.BodyLimit = 4194304
+	value := GetEnvOrDefault("SERVER_BODY_LIMIT", "4194304")
+	if bodyLimit, err := strconv.Atoi(value); err != nil || bodyLimit < 1 {
+		problems = append(problems, fmt.Sprintf("SERVER_BODY_LIMIT: %q is not a positive number", value))
+	} else {
+		loaded.Server.BodyLimit = bodyLimit
+	}
+	return problems
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Outbox holds the settings of the outbox dispatcher.
+type Outbox struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	RetryBase    time.Duration
+	MaxBackoff   time.Duration
+	Concurrency  int
+}
+
+// Scheduler holds the settings of the background jobs, LeaseDuration is how long a crashed run
+// holds its job, running jobs renew their lease.
+type Scheduler struct {
+	PollInterval     time.Duration
+	LeaderTTL        time.Duration
+	LeaseDuration    time.Duration
+	RetryBase        time.Duration
+	MaxBackoff       time.Duration
+	ReminderSchedule string
+	CleanupSchedule  string
+}
+
+// Feed holds the settings of the websocket activity feed.
+type Feed struct {
+	Buffer       int
+	PingInterval time.Duration
+	WriteTimeout time.Duration
+}
+
+// Stream holds the settings of the Server-Sent Events streams.
+type Stream struct {
+	HeartbeatInterval time.Duration
+}
+
+// Webhooks holds the settings of the webhook deliveries.
+type Webhooks struct {
+	Timeout time.Duration
+}
+
+// Tickets holds the settings of the ticket codes, the signing key is a secret and is read by the tickets package.
+type Tickets struct {
+	Validity time.Duration
+}
+
+// Idempotency holds how long the responses of idempotent requests are kept and how long a request
+// in progress blocks its key.
+type Idempotency struct {
+	Retention         time.Duration
+	InProgressTimeout time.Duration
+}
+
+// Health holds how long a readiness check of a component may take.
+type Health struct {
+	CheckTimeout time.Duration
+}
+
+// API holds the settings of the API versions.
+type API struct {
+	UnversionedSunset time.Time
+}
+
+// Notify holds the settings of the customer notifications. Transport is smtp, log or none,
+// TemplateDir holds templates replacing the built-in ones.
+type Notify struct {
+	Transport   string
+	TemplateDir string
+	SMTP        SMTP
+}
+
+// SMTP holds the settings of the mail server of the smtp transport.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// loadServices reads the settings of the workers and handlers into loaded: OUTBOX_POLL_INTERVAL (1s),
+// OUTBOX_MAX_ATTEMPTS (8), OUTBOX_RETRY_BASE (30s), OUTBOX_MAX_BACKOFF (1h), OUTBOX_CONCURRENCY (4),
+// SCHEDULER_POLL_INTERVAL (30s), SCHEDULER_LEADER_TTL (90s), JOB_LEASE_DURATION (10m), JOB_RETRY_BASE (1m),
+// JOB_MAX_BACKOFF (1h), REMINDER_SCHEDULE (*/15 * * * *), CLEANUP_SCHEDULE (@hourly), FEED_BUFFER (64),
+// FEED_PING_INTERVAL (30s), FEED_WRITE_TIMEOUT (10s), SSE_HEARTBEAT_INTERVAL (15s), WEBHOOK_TIMEOUT (10s),
+// TICKET_VALIDITY (8760h), IDEMPOTENCY_RETENTION (24h), IDEMPOTENCY_IN_PROGRESS_TIMEOUT (1m),
+// HEALTH_CHECK_TIMEOUT (2s) and API_UNVERSIONED_SUNSET (2027-06-30). Durations and numbers have to be positive. Invalid settings keep
+// their default and are reported.
+func loadServices(loaded *Config) Errors {
+	var problems Errors
+
+	durations := []struct {
+		key          string
+		defaultValue string
+		value        *time.Duration
+	}{
+		{"OUTBOX_POLL_INTERVAL", "1s", &loaded.Outbox.PollInterval},
+		{"OUTBOX_RETRY_BASE", "30s", &loaded.Outbox.RetryBase},
+		{"OUTBOX_MAX_BACKOFF", "1h", &loaded.Outbox.MaxBackoff},
+		{"SCHEDULER_POLL_INTERVAL", "30s", &loaded.Scheduler.PollInterval},
+		{"SCHEDULER_LEADER_TTL", "90s", &loaded.Scheduler.LeaderTTL},
+		{"JOB_LEASE_DURATION", "10m", &loaded.Scheduler.LeaseDuration},
+		{"JOB_RETRY_BASE", "1m", &loaded.Scheduler.RetryBase},
+		{"JOB_MAX_BACKOFF", "1h", &loaded.Scheduler.MaxBackoff},
+		{"FEED_PING_INTERVAL", "30s", &loaded.Feed.PingInterval},
+		{"FEED_WRITE_TIMEOUT", "10s", &loaded.Feed.WriteTimeout},
+		{"SSE_HEARTBEAT_INTERVAL", "15s", &loaded.Stream.HeartbeatInterval},
+		{"WEBHOOK_TIMEOUT", "10s", &loaded.Webhooks.Timeout},
+		{"TICKET_VALIDITY", "8760h", &loaded.Tickets.Validity},
+		{"IDEMPOTENCY_RETENTION", "24h", &loaded.Idempotency.Retention},
+		{"IDEMPOTENCY_IN_PROGRESS_TIMEOUT", "1m", &loaded.Idempotency.InProgressTimeout},
+		{"HEALTH_CHECK_TIMEOUT", "2s", &loaded.Health.CheckTimeout},
+	}
+	for _, duration := range durations {
+		*duration.value, _ = time.ParseDuration(duration.defaultValue)
+		value := GetEnvOrDefault(duration.key, duration.defaultValue)
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			problems = append(problems, fmt.Sprintf("%v: %q is not a positive duration", duration.key, value))
+			continue
+		}
+		*duration.value = parsed
+	}
+
+	numbers := []struct {
+		key          string
+		defaultValue int
+		value        *int
+	}{
+		{"OUTBOX_MAX_ATTEMPTS", 8, &loaded.Outbox.MaxAttempts},
+		{"OUTBOX_CONCURRENCY", 4, &loaded.Outbox.Concurrency},
+		{"FEED_BUFFER", 64, &loaded.Feed.Buffer},
+	}
+	for _, number := range numbers {
+		*number.value = number.defaultValue
+		value := GetEnvOrDefault(number.key, strconv.Itoa(number.defaultValue))
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			problems = append(problems, fmt.Sprintf("%v: %q is not a positive number", number.key, value))
+			continue
+		}
+		*number.value = parsed
+	}
+
+	loaded.Scheduler.ReminderSchedule = GetEnvOrDefault("REMINDER_SCHEDULE", "*/15 * * * *")
+	loaded.Scheduler.CleanupSchedule = GetEnvOrDefault("CLEANUP_SCHEDULE", "@hourly")
+
+	loaded.API.UnversionedSunset, _ = time.Parse("2006-01-02", "2027-06-30")
+	if value := GetEnvOrDefault("API_UNVERSIONED_SUNSET", "2027-06-30"); value != "" {
+		sunset, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("API_UNVERSIONED_SUNSET: %q is not a date like 2006-01-02", value))
+		} else {
+			loaded.API.UnversionedSunset = sunset
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// loadNotify reads NOTIFY_TRANSPORT, smtp when SMTP_HOST is set and none otherwise, NOTIFY_TEMPLATE_DIR and
+// the SMTP settings SMTP_HOST (localhost), SMTP_PORT (25), SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+// (bookings@localhost). The transport is checked by validate.
+func loadNotify(loaded *Config) Errors {
+	var problems Errors
+
+	defaultTransport := "none"
+	if _, exist := Lookup("SMTP_HOST"); exist {
+		defaultTransport = "smtp"
+	}
+	loaded.Notify = Notify{
+		Transport:   GetEnvOrDefault("NOTIFY_TRANSPORT", defaultTransport),
+		TemplateDir: GetEnvOrDefault("NOTIFY_TEMPLATE_DIR", ""),
+		SMTP: SMTP{
+			Host:     GetEnvOrDefault("SMTP_HOST", "localhost"),
+			Port:     25,
+			Username: GetEnvOrDefault("SMTP_USERNAME", ""),
+			Password: GetEnvOrDefault("SMTP_PASSWORD", ""),
+			From:     GetEnvOrDefault("SMTP_FROM", "bookings@localhost"),
+		},
+	}
+
+	value := GetEnvOrDefault("SMTP_PORT", "25")
+	if port, err := strconv.Atoi(value); err != nil || port < 1 {
+		problems = append(problems, fmt.Sprintf("SMTP_PORT: %q is not a positive number", value))
+	} else {
+		loaded.Notify.SMTP.Port = port
+	}
+	return problems
+}
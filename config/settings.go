@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kind tells how a setting is validated.
+type kind int
+
+const (
+	kindString kind = iota
+	kindSecret
+	kindDuration
+	kindInt
+	kindDate
+	kindNotifyTransport
+	kindLogLevel
+	kindPattern
+)
+
+// settings are all settings the service reads. Unknown keys in the config file or the flags are
+// reported, the environment is shared with the rest of the system and is only checked for known keys.
+var settings = map[string]kind{
+	"CONFIG_FILE": kindString,
+	"LOG_LEVEL":   kindLogLevel,
+
+	"SERVER_ADDR":          kindString,
+	"SERVER_READ_TIMEOUT":  kindDuration,
+	"SERVER_WRITE_TIMEOUT": kindDuration,
+	"SERVER_IDLE_TIMEOUT":  kindDuration,
+	"SERVER_BODY_LIMIT":    kindInt,
+	"SHUTDOWN_TIMEOUT":     kindDuration,
+	"SHUTDOWN_DRAIN_DELAY": kindDuration,
+	"HEALTH_CHECK_TIMEOUT": kindDuration,
+
+	"SIGN":               kindSecret,
+	"MONGODB_CONNSTRING": kindSecret,
+	"MONGODB_DATABASE":   kindString,
+	"TICKET_PRIVATE_KEY": kindSecret,
+	"TICKET_VALIDITY":    kindDuration,
+
+	"LOCAL_DB_PATH":                   kindString,
+	"EVENT_LOG_PATH":                  kindString,
+	"PROJECTION_POSITION_PATH":        kindString,
+	"AUDIT_LOG_PATH":                  kindString,
+	"IDEMPOTENCY_DB_PATH":             kindString,
+	"IDEMPOTENCY_RETENTION":           kindDuration,
+	"IDEMPOTENCY_IN_PROGRESS_TIMEOUT": kindDuration,
+	"OUTBOX_DB_PATH":                  kindString,
+	"OUTBOX_POLL_INTERVAL":            kindDuration,
+	"OUTBOX_MAX_ATTEMPTS":             kindInt,
+	"OUTBOX_RETRY_BASE":               kindDuration,
+	"OUTBOX_MAX_BACKOFF":              kindDuration,
+	"OUTBOX_CONCURRENCY":              kindInt,
+	"WEBHOOKS_DB_PATH":                kindString,
+	"WEBHOOK_DELIVERY_LOG_PATH":       kindString,
+	"WEBHOOK_TIMEOUT":                 kindDuration,
+	"JOBS_DB_PATH":                    kindString,
+
+	"NOTIFY_TRANSPORT":    kindNotifyTransport,
+	"NOTIFY_TEMPLATE_DIR": kindString,
+	"SMTP_HOST":           kindString,
+	"SMTP_PORT":           kindInt,
+	"SMTP_USERNAME":       kindString,
+	"SMTP_PASSWORD":       kindSecret,
+	"SMTP_FROM":           kindString,
+
+	"API_UNVERSIONED_SUNSET": kindDate,
+	"SSE_HEARTBEAT_INTERVAL": kindDuration,
+	"FEED_BUFFER":            kindInt,
+	"FEED_PING_INTERVAL":     kindDuration,
+	"FEED_WRITE_TIMEOUT":     kindDuration,
+
+	"SCHEDULER_POLL_INTERVAL": kindDuration,
+	"SCHEDULER_LEADER_TTL":    kindDuration,
+	"JOB_LEASE_DURATION":      kindDuration,
+	"JOB_RETRY_BASE":          kindDuration,
+	"JOB_MAX_BACKOFF":         kindDuration,
+	"REMINDER_SCHEDULE":       kindString,
+	"CLEANUP_SCHEDULE":        kindString,
+
+	// the rules of the validation package, see validation.LoadRules
+	"VALIDATION_NAME_MIN_LENGTH":            kindInt,
+	"VALIDATION_NAME_CHARS":                 kindPattern,
+	"VALIDATION_CONFERENCE_NAME_MAX_LENGTH": kindInt,
+	"VALIDATION_CUSTOMER_NAME_MAX_LENGTH":   kindInt,
+	"VALIDATION_CUSTOMER_EMAIL_MAX_LENGTH":  kindInt,
+	"VALIDATION_MAX_TOTAL_TICKETS":          kindInt,
+	"VALIDATION_MAX_TICKETS_PER_BOOKING":    kindInt,
+}
+
+// known tells whether the key is a setting of the service, KEY_FILE of a known KEY included.
+func known(key string) bool {
+	_, ok := settings[strings.TrimSuffix(key, "_FILE")]
+	return ok
+}
+
+// check returns an error if the value is invalid for the kind.
+func (k kind) check(value string) error {
+	switch k {
+	case kindDuration:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 5m", value)
+		}
+		if duration < 0 {
+			return fmt.Errorf("%q is negative", value)
+		}
+	case kindInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		if number < 0 {
+			return fmt.Errorf("%q is negative", value)
+		}
+	case kindDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("%q is not a date like 2006-01-02", value)
+		}
+	case kindNotifyTransport:
+		if value != "smtp" && value != "log" && value != "none" {
+			return fmt.Errorf("%q is not one of smtp, log, none", value)
+		}
+	case kindLogLevel:
+		if value != "debug" && value != "info" && value != "warn" && value != "error" {
+			return fmt.Errorf("%q is not one of debug, info, warn, error", value)
+		}
+	case kindPattern:
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("%q is not a regular expression: %v", value, err)
+		}
+	}
+	return nil
+}
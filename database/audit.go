@@ -0,0 +1,139 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/metrics"
+	"booking-webapp/model"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var auditMutex sync.Mutex
+
+// AuditFilter narrows down the audit log, empty fields match every entry.
+type AuditFilter struct {
+	Entity       string
+	EntityId     string
+	ConferenceId string
+	Actor        string
+	Action       string
+	From         time.Time
+	To           time.Time
+}
+
+// AppendAuditEntry appends the entry to the audit log, entries are never changed afterwards.
+func AppendAuditEntry(entry model.AuditEntry) error {
+	defer metrics.ObserveStorage("append_audit", time.Now())
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	auditFile, err := os.OpenFile(config.AUDIT_LOG_PATH, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer auditFile.Close()
+
+	_, err = auditFile.Write(append(entryBytes, '\n'))
+	return err
+}
+
+// ReadAuditEntries returns the entries matching the filter in the order they were recorded.
+func ReadAuditEntries(filter AuditFilter) ([]model.AuditEntry, error) {
+	entries := []model.AuditEntry{}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	auditFile, err := os.Open(config.AUDIT_LOG_PATH)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer auditFile.Close()
+
+	scanner := bufio.NewScanner(auditFile)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var entry model.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("broken audit log entry at line %v: %v", lineNumber, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+func (filter AuditFilter) matches(entry model.AuditEntry) bool {
+	if (filter.Entity != "" && filter.Entity != entry.Entity) ||
+		(filter.EntityId != "" && filter.EntityId != entry.EntityId) ||
+		(filter.ConferenceId != "" && filter.ConferenceId != entry.ConferenceId) ||
+		(filter.Actor != "" && filter.Actor != entry.Actor) ||
+		(filter.Action != "" && filter.Action != entry.Action) {
+		return false
+	}
+
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timestamp, err := time.Parse(time.RFC3339, entry.Timestamp)
+		if err != nil {
+			return false
+		}
+		if (!filter.From.IsZero() && timestamp.Before(filter.From)) || (!filter.To.IsZero() && timestamp.After(filter.To)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AuditChanges compares the JSON fields of two versions of an entity and returns the changed ones.
+// Versions are passed as pointers, either can be nil for created or deleted entities.
+// Ignored fields are left out.
+func AuditChanges(before interface{}, after interface{}, ignoredFields ...string) map[string]model.FieldChange {
+	beforeFields := jsonFields(before)
+	afterFields := jsonFields(after)
+
+	changes := map[string]model.FieldChange{}
+	for field, beforeVal := range beforeFields {
+		if afterVal := afterFields[field]; !reflect.DeepEqual(beforeVal, afterVal) {
+			changes[field] = model.FieldChange{Before: beforeVal, After: afterVal}
+		}
+	}
+	for field, afterVal := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = model.FieldChange{Before: nil, After: afterVal}
+		}
+	}
+
+	for _, field := range ignoredFields {
+		delete(changes, field)
+	}
+	return changes
+}
+
+func jsonFields(entity interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if entity == nil || (reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil()) {
+		return fields
+	}
+
+	entityBytes, err := json.Marshal(entity)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(entityBytes, &fields)
+	return fields
+}
@@ -2,14 +2,17 @@ package database
 
 import (
 	"booking-webapp/config"
+	"booking-webapp/ledger"
+	"booking-webapp/metrics"
 	"booking-webapp/model"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,10 +22,13 @@ import (
 )
 
 var ctx = context.TODO()
+var clientMutex sync.Mutex
+var client *mongo.Client
 var UsersCollection *mongo.Collection
 var ConferencesCollection *mongo.Collection
 
 func ReadLocalDB() ([]model.Conference, error) {
+	defer metrics.ObserveStorage("read_conferences", time.Now())
 	conferences := []model.Conference{}
 
 	fileBytes, err := os.ReadFile(config.LOCAL_DB_PATH)
@@ -41,18 +47,20 @@ func ReadLocalDB() ([]model.Conference, error) {
 	return conferences, nil
 }
 
+// CommitConferencesToLocalDB replaces the conferences file through a rename, so readers without the
+// ledger lock, e.g. in another process, never see a half written file.
 func CommitConferencesToLocalDB(conferences []model.Conference) error {
+	defer metrics.ObserveStorage("write_conferences", time.Now())
 	conferencesBytes, err := json.MarshalIndent(conferences, "", "	")
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(config.LOCAL_DB_PATH, conferencesBytes, 0644)
-	if err != nil {
+	tmpPath := config.LOCAL_DB_PATH + ".tmp"
+	if err := os.WriteFile(tmpPath, conferencesBytes, 0644); err != nil {
 		return err
 	}
-
-	return nil
+	return os.Rename(tmpPath, config.LOCAL_DB_PATH)
 }
 
 func GetConference(confId string) (model.Conference, error) {
@@ -113,36 +121,51 @@ func CommitConferenceToLocalDB(conference model.Conference) error {
 }
 
 func GetTotalBookings(conf model.Conference) uint {
-	var totalBookings uint = 0
-	for _, booking := range conf.Bookings {
-		if !booking.IsCanceled {
-			totalBookings += booking.TicketsBooked
-		}
-	}
-	return totalBookings
+	return ledger.BookedTickets(conf)
 }
 
+// DBInit returns the collection of the MONGODB_DATABASE database. The Mongo client is connected on the
+// first call and shared by all collections.
 func DBInit(collectionName string) (*mongo.Collection, error) {
-	connString, err := config.GetSecret("MONGODB_CONNSTRING")
-	if err != nil {
-		log.Fatal("cannot find connection string for DB in the environment")
-	}
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
 
-	clientOptions := options.Client().ApplyURI(connString)
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		return nil, fmt.Errorf("cannot connect to the db: %v", err)
-	}
+	if client == nil {
+		connString, err := config.GetSecret("MONGODB_CONNSTRING")
+		if err != nil {
+			return nil, fmt.Errorf("cannot find connection string for DB in the environment: %v", err)
+		}
 
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("db is not available: %v", err)
+		connected, err := mongo.Connect(ctx, options.Client().ApplyURI(connString))
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect to the db: %v", err)
+		}
+
+		if err := connected.Ping(ctx, nil); err != nil {
+			connected.Disconnect(ctx)
+			return nil, fmt.Errorf("db is not available: %v", err)
+		}
+		client = connected
 	}
 
-	return client.Database("booking-service").Collection(collectionName), nil
+	return client.Database(config.MONGODB_DATABASE).Collection(collectionName), nil
+}
+
+// Disconnect closes the Mongo client, the collections cannot be used afterwards.
+func Disconnect(disconnectCtx context.Context) error {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	err := client.Disconnect(disconnectCtx)
+	client = nil
+	return err
 }
 
 func GetUserData(userLogin string) (model.UserData, error) {
+	defer metrics.ObserveStorage("mongo_find_user", time.Now())
 	var user model.UserData
 	cur, err := UsersCollection.Find(ctx, bson.D{primitive.E{Key: "login", Value: userLogin}})
 	if err != nil {
@@ -0,0 +1,459 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/ledger"
+	"booking-webapp/logging"
+	"booking-webapp/metrics"
+	"booking-webapp/model"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEventRejected is returned by ApplyEvents when the events break the conference state,
+// e.g. a booking which does not fit into the remaining tickets anymore.
+var ErrEventRejected = errors.New("change rejected")
+
+// ledgerMutex guards the ledger within the process, see lockLedger for the lock between processes.
+var ledgerMutex sync.Mutex
+
+// CommitHook is called after events were committed with the new state of their conference,
+// deleted tells that the conference was deleted. Hooks run while the ledger is locked and must not block.
+type CommitHook func(events []model.Event, conference model.Conference, deleted bool)
+
+var commitHooks []CommitHook
+
+// OnCommit registers a hook for the changes applied through ApplyEvents.
+func OnCommit(hook CommitHook) {
+	ledgerMutex.Lock()
+	defer ledgerMutex.Unlock()
+	commitHooks = append(commitHooks, hook)
+}
+
+// ReadEvents returns the booking ledger in the order the events were recorded.
+func ReadEvents() ([]model.Event, error) {
+	defer metrics.ObserveStorage("read_events", time.Now())
+	events := []model.Event{}
+
+	eventsFile, err := os.Open(config.EVENT_LOG_PATH)
+	if os.IsNotExist(err) {
+		return events, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer eventsFile.Close()
+
+	scanner := bufio.NewScanner(eventsFile)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var event model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("broken event at line %v of the event log: %v", lineNumber, err)
+		}
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+// ReadEventsFrom returns the events recorded after the byte offset of the event log and the offset
+// after them, so a reader following the log continues where it stopped. An event which is still
+// being written is left for the next read.
+func ReadEventsFrom(offset int64) ([]model.Event, int64, error) {
+	defer metrics.ObserveStorage("read_events", time.Now())
+	events := []model.Event{}
+
+	eventsFile, err := os.Open(config.EVENT_LOG_PATH)
+	if os.IsNotExist(err) {
+		return events, offset, nil
+	} else if err != nil {
+		return nil, offset, err
+	}
+	defer eventsFile.Close()
+
+	if _, err := eventsFile.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	reader := bufio.NewReader(eventsFile)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return events, offset, nil
+		} else if err != nil {
+			return nil, offset, err
+		}
+
+		var event model.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, offset, fmt.Errorf("broken event at byte %v of the event log: %v", offset, err)
+		}
+		events = append(events, event)
+		offset += int64(len(line))
+	}
+}
+
+// lastEventSeq returns the sequence number of the last recorded event, 0 for an empty log.
+// Only the tail of the log is read, so appending does not get slower as the log grows.
+func lastEventSeq() (uint64, error) {
+	eventsFile, err := os.Open(config.EVENT_LOG_PATH)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer eventsFile.Close()
+
+	info, err := eventsFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+	const chunkSize = 4096
+	tail := []byte{}
+	for end := info.Size(); end > 0; {
+		start := end - chunkSize
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, end-start)
+		if _, err := eventsFile.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		tail = append(chunk, tail...)
+		end = start
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		newline := bytes.LastIndexByte(trimmed, '\n')
+		if newline == -1 && end > 0 {
+			// the last event is longer than the tail read so far
+			continue
+		}
+		lastLine := trimmed[newline+1:]
+		if len(lastLine) == 0 {
+			return 0, nil
+		}
+		var event model.Event
+		if err := json.Unmarshal(lastLine, &event); err != nil {
+			return 0, fmt.Errorf("broken last event of the event log: %v", err)
+		}
+		return event.Seq, nil
+	}
+	return 0, nil
+}
+
+// appendEvents numbers the events after the last recorded one and writes them at once.
+func appendEvents(events []model.Event) ([]model.Event, error) {
+	defer metrics.ObserveStorage("append_events", time.Now())
+	lastSeq, err := lastEventSeq()
+	if err != nil {
+		return nil, err
+	}
+
+	eventsBytes := []byte{}
+	for eventIndex := range events {
+		lastSeq++
+		events[eventIndex].Seq = lastSeq
+		eventBytes, err := json.Marshal(events[eventIndex])
+		if err != nil {
+			return nil, err
+		}
+		eventsBytes = append(append(eventsBytes, eventBytes...), '\n')
+	}
+
+	eventsFile, err := os.OpenFile(config.EVENT_LOG_PATH, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer eventsFile.Close()
+
+	if _, err := eventsFile.Write(eventsBytes); err != nil {
+		return nil, err
+	}
+	return events, eventsFile.Sync()
+}
+
+func readProjectionPosition() (uint64, error) {
+	positionBytes, err := os.ReadFile(config.PROJECTION_POSITION_PATH)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(positionBytes)), 10, 64)
+}
+
+func commitProjectionPosition(seq uint64) error {
+	return os.WriteFile(config.PROJECTION_POSITION_PATH, []byte(strconv.FormatUint(seq, 10)), 0644)
+}
+
+// InitLedger starts the event log from the stored conferences if it does not exist yet,
+// and rebuilds the projections when they are behind the log, e.g. after a crash between both writes.
+func InitLedger() error {
+	unlock, err := lockLedger()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	seeded, err := seedLedger()
+	if err != nil || seeded {
+		return err
+	}
+	return catchUpProjections()
+}
+
+// catchUpProjections rebuilds the projections when they are behind the event log.
+func catchUpProjections() error {
+	position, err := readProjectionPosition()
+	if err != nil {
+		return fmt.Errorf("cannot read projection position: %v", err)
+	}
+	lastSeq, err := lastEventSeq()
+	if err != nil {
+		return err
+	}
+	if lastSeq > position {
+		_, err = rebuildProjections()
+	}
+	return err
+}
+
+// seedLedger records the events leading to the stored conferences if there is no event log yet.
+func seedLedger() (bool, error) {
+	if _, err := os.Stat(config.EVENT_LOG_PATH); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	conferences, err := ReadLocalDB()
+	if err != nil {
+		return false, err
+	}
+
+	seededAt := time.Now().Format(time.RFC3339)
+	events := []model.Event{}
+	for _, conference := range conferences {
+		events = append(events, ledger.Seed(conference, seededAt)...)
+	}
+	if _, err := appendEvents(events); err != nil {
+		return false, fmt.Errorf("cannot seed event log: %v", err)
+	}
+
+	_, err = rebuildProjections()
+	return true, err
+}
+
+// ApplyEvents checks the events of a single conference against its current state, appends them
+// to the ledger and updates the conference projection. The new conference state is returned.
+func ApplyEvents(events ...model.Event) (model.Conference, error) {
+	conferences, err := ApplyConferenceEvents([][]model.Event{events})
+	if err != nil {
+		return model.Conference{}, err
+	}
+	return conferences[0], nil
+}
+
+// ApplyConferenceEvents works like ApplyEvents for the changes of several conferences, every change holds
+// the events of one conference. Either all changes are committed or none, e.g. for an import.
+// The new conference states are returned in the order of the changes. Projections which could not be
+// written after a commit are rebuilt from the log, changes are refused while they stay behind it.
+func ApplyConferenceEvents(changes [][]model.Event) ([]model.Conference, error) {
+	defer metrics.ObserveStorage("apply_events", time.Now())
+	for _, events := range changes {
+		if len(events) == 0 {
+			return nil, errors.New("no events to apply")
+		}
+	}
+
+	unlock, err := lockLedger()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := seedLedger(); err != nil {
+		return nil, err
+	}
+	// no change is checked against projections which missed committed events
+	if err := catchUpProjections(); err != nil {
+		return nil, fmt.Errorf("projections are behind the event log: %v", err)
+	}
+
+	conferences, err := ReadLocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]model.Conference, len(changes))
+	deleted := make([]bool, len(changes))
+	allEvents := []model.Event{}
+	for changeIndex, events := range changes {
+		if states[changeIndex], deleted[changeIndex], conferences, err = applyChange(conferences, events); err != nil {
+			return nil, err
+		}
+		allEvents = append(allEvents, events...)
+	}
+
+	committed, err := appendEvents(allEvents)
+	if err != nil {
+		logging.Default.Error("cannot append events to the event log", "request_id", allEvents[0].RequestId, "error", err)
+		return nil, err
+	}
+	if err := commitProjections(conferences, committed[len(committed)-1].Seq); err != nil {
+		// the events are committed, the projections are derived from the log again
+		logging.Default.Error("cannot update the projections, rebuilding them", "request_id", allEvents[0].RequestId, "error", err)
+		if _, err := rebuildProjections(); err != nil {
+			return nil, fmt.Errorf("events are committed, but the projections are behind the event log: %v", err)
+		}
+	}
+
+	for changeIndex, events := range changes {
+		events, committed = committed[:len(events)], committed[len(events):]
+
+		eventTypes := make([]string, len(events))
+		for index, event := range events {
+			eventTypes[index] = event.Type
+		}
+		// the request id of the events ties the storage logs to the request logs
+		logging.Default.Info("events committed", "request_id", events[0].RequestId, "conference_id", events[0].ConferenceId,
+			"from_seq", events[0].Seq, "to_seq", events[len(events)-1].Seq, "types", eventTypes)
+
+		for _, hook := range commitHooks {
+			hook(events, states[changeIndex], deleted[changeIndex])
+		}
+	}
+	return states, nil
+}
+
+func commitProjections(conferences []model.Conference, seq uint64) error {
+	if err := CommitConferencesToLocalDB(conferences); err != nil {
+		return err
+	}
+	return commitProjectionPosition(seq)
+}
+
+// applyChange checks the events of a single conference against its state in conferences and returns
+// the new state, whether the conference was deleted and the updated conferences.
+func applyChange(conferences []model.Conference, events []model.Event) (model.Conference, bool, []model.Conference, error) {
+	confId := events[0].ConferenceId
+	confIndex := -1
+	for index, conference := range conferences {
+		if conference.Id == confId {
+			confIndex = index
+		}
+	}
+	if confIndex == -1 && events[0].Type != model.EventConferenceCreated {
+		return model.Conference{}, false, nil, fmt.Errorf("no conference with id %v in database", confId)
+	}
+
+	var conference model.Conference
+	if confIndex != -1 {
+		conference = conferences[confIndex]
+	}
+	isDeleted := false
+	var err error
+	for _, event := range events {
+		if event.ConferenceId != confId {
+			return model.Conference{}, false, nil, fmt.Errorf("events of conferences %v and %v cannot be applied together", confId, event.ConferenceId)
+		}
+		if event.Type == model.EventConferenceDeleted {
+			isDeleted = true
+			continue
+		}
+		if conference, err = ledger.Apply(conference, event); err != nil {
+			return model.Conference{}, false, nil, fmt.Errorf("%w: %v", ErrEventRejected, err)
+		}
+	}
+	if err := ledger.Check(conference); !isDeleted && err != nil {
+		return model.Conference{}, false, nil, fmt.Errorf("%w: %v", ErrEventRejected, err)
+	}
+
+	// the conferences of the caller stay untouched until all changes were checked
+	updated := append([]model.Conference{}, conferences...)
+	switch {
+	case isDeleted:
+		updated = append(updated[:confIndex], updated[confIndex+1:]...)
+	case confIndex == -1:
+		updated = append(updated, conference)
+	default:
+		updated[confIndex] = conference
+	}
+	return conference, isDeleted, updated, nil
+}
+
+// RebuildProjections derives all conferences from the event log again and replaces the stored ones.
+// It returns the number of replayed events.
+func RebuildProjections() (int, error) {
+	unlock, err := lockLedger()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if _, err := seedLedger(); err != nil {
+		return 0, err
+	}
+	return rebuildProjections()
+}
+
+func rebuildProjections() (int, error) {
+	events, err := ReadEvents()
+	if err != nil {
+		return 0, err
+	}
+
+	conferences, err := ledger.Replay(events)
+	if err != nil {
+		return 0, err
+	}
+	if err := CommitConferencesToLocalDB(conferences); err != nil {
+		return 0, err
+	}
+
+	var position uint64 = 0
+	if len(events) > 0 {
+		position = events[len(events)-1].Seq
+	}
+	return len(events), commitProjectionPosition(position)
+}
+
+// RecomputeRemainingTickets sets remaining tickets of the stored conferences to total tickets minus
+// the active bookings and returns the ids of the corrected conferences.
+func RecomputeRemainingTickets() ([]string, error) {
+	unlock, err := lockLedger()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	conferences, err := ReadLocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := []string{}
+	for confIndex, conference := range conferences {
+		var remainingTickets uint = 0
+		if bookedTickets := ledger.BookedTickets(conference); conference.TotalTickets > bookedTickets {
+			remainingTickets = conference.TotalTickets - bookedTickets
+		}
+		if conference.RemainingTickets != remainingTickets {
+			conferences[confIndex].RemainingTickets = remainingTickets
+			fixed = append(fixed, conference.Id)
+		}
+	}
+
+	if len(fixed) == 0 {
+		return fixed, nil
+	}
+	return fixed, CommitConferencesToLocalDB(conferences)
+}
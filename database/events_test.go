@@ -0,0 +1,141 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestLedger points the event log and the projections to a temp dir.
+func useTestLedger(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []*string{&config.EVENT_LOG_PATH, &config.LOCAL_DB_PATH, &config.PROJECTION_POSITION_PATH} {
+		path, prevPath := path, *path
+		*path = filepath.Join(dir, filepath.Base(prevPath))
+		t.Cleanup(func() { *path = prevPath })
+	}
+}
+
+func TestAppendEventsContinuesAfterTheLastEvent(t *testing.T) {
+	useTestLedger(t)
+
+	lastSeq, err := lastEventSeq()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), lastSeq)
+
+	// events longer than a chunk of the tail
+	longName := strings.Repeat("Boston ", 1000)
+	for round := 0; round < 3; round++ {
+		_, err := appendEvents([]model.Event{
+			{Type: model.EventConferenceRenamed, ConferenceId: "conf1", ConferenceName: longName},
+			{Type: model.EventConferenceRenamed, ConferenceId: "conf1", ConferenceName: "Boston"},
+		})
+		assert.NoError(t, err)
+	}
+	_, err = appendEvents([]model.Event{{Type: model.EventConferenceRenamed, ConferenceId: "conf1", ConferenceName: longName}})
+	assert.NoError(t, err)
+
+	events, err := ReadEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 7)
+	for index, event := range events {
+		assert.Equal(t, uint64(index+1), event.Seq)
+	}
+	lastSeq, err = lastEventSeq()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), lastSeq)
+}
+
+func TestReadEventsFromContinuesAtOffset(t *testing.T) {
+	useTestLedger(t)
+	_, err := appendEvents([]model.Event{{Type: model.EventConferenceRenamed, ConferenceId: "conf1", ConferenceName: "Boston"}})
+	assert.NoError(t, err)
+
+	events, offset, err := ReadEventsFrom(0)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	_, err = appendEvents([]model.Event{{Type: model.EventConferenceRenamed, ConferenceId: "conf1", ConferenceName: "Oslo"}})
+	assert.NoError(t, err)
+	// an event which is still being written
+	eventsFile, err := os.OpenFile(config.EVENT_LOG_PATH, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = eventsFile.WriteString(`{"seq":3,"type":"conf`)
+	assert.NoError(t, err)
+	eventsFile.Close()
+
+	events, next, err := ReadEventsFrom(offset)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "Oslo", events[0].ConferenceName)
+	}
+	events, _, err = ReadEventsFrom(next)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestApplyConferenceEventsIsAtomic(t *testing.T) {
+	useTestLedger(t)
+	assert.NoError(t, InitLedger())
+
+	created := func(confId string) model.Event {
+		return model.Event{Type: model.EventConferenceCreated, ConferenceId: confId, ConferenceName: confId, TotalTickets: 10}
+	}
+	booked := model.Event{Type: model.EventBookingCreated, ConferenceId: "oslo", BookingId: "booking1", CustomerName: "Jane Doe", TicketsBooked: 11}
+
+	_, err := ApplyConferenceEvents([][]model.Event{{created("boston")}, {created("oslo"), booked}})
+	assert.ErrorIs(t, err, ErrEventRejected)
+	conferences, err := ReadLocalDB()
+	assert.NoError(t, err)
+	assert.Empty(t, conferences)
+	events, err := ReadEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	booked.TicketsBooked = 2
+	states, err := ApplyConferenceEvents([][]model.Event{{created("boston")}, {created("oslo"), booked}})
+	assert.NoError(t, err)
+	if assert.Len(t, states, 2) {
+		assert.Equal(t, "boston", states[0].Id)
+		assert.Equal(t, uint(8), states[1].RemainingTickets)
+	}
+	conferences, err = ReadLocalDB()
+	assert.NoError(t, err)
+	assert.Len(t, conferences, 2)
+	position, err := readProjectionPosition()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), position)
+}
+
+func TestApplyEventsCatchesUpAfterAFailedProjection(t *testing.T) {
+	useTestLedger(t)
+	assert.NoError(t, InitLedger())
+	_, err := ApplyEvents(model.Event{Type: model.EventConferenceCreated, ConferenceId: "boston", ConferenceName: "Boston", TotalTickets: 10})
+	assert.NoError(t, err)
+
+	// the conferences cannot be written while their temp file is a directory
+	assert.NoError(t, os.Mkdir(config.LOCAL_DB_PATH+".tmp", 0755))
+	booked := model.Event{Type: model.EventBookingCreated, ConferenceId: "boston", BookingId: "booking1", CustomerName: "Jane Doe", TicketsBooked: 2}
+	_, err = ApplyEvents(booked)
+	assert.ErrorContains(t, err, "events are committed")
+	booked.BookingId = "booking2"
+	_, err = ApplyEvents(booked)
+	assert.ErrorContains(t, err, "projections are behind the event log")
+	events, err := ReadEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 2, "writes are refused while the projections are behind")
+
+	assert.NoError(t, os.Remove(config.LOCAL_DB_PATH+".tmp"))
+	conference, err := ApplyEvents(booked)
+	assert.NoError(t, err)
+	assert.Len(t, conference.Bookings, 2)
+	assert.Equal(t, uint(6), conference.RemainingTickets)
+	position, err := readProjectionPosition()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), position)
+}
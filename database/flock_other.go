@@ -0,0 +1,12 @@
+//go:build !unix
+
+package database
+
+import (
+	"os"
+)
+
+// flockExclusive does nothing where flock is not available, only the processes are not locked against each other.
+func flockExclusive(file *os.File) error {
+	return nil
+}
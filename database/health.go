@@ -0,0 +1,45 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Ping checks that Mongo answers on the shared client.
+func Ping(pingCtx context.Context) error {
+	clientMutex.Lock()
+	connected := client
+	clientMutex.Unlock()
+
+	if connected == nil {
+		return errors.New("not connected")
+	}
+	return connected.Ping(pingCtx, nil)
+}
+
+// CheckStorage checks that the conference snapshot and the event log can be read and that the
+// directories of the other local files, which are created on first use, exist.
+func CheckStorage(ctx context.Context) error {
+	for _, path := range []string{config.LOCAL_DB_PATH, config.EVENT_LOG_PATH} {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %v: %v", path, err)
+		}
+		file.Close()
+	}
+
+	paths := []string{
+		config.PROJECTION_POSITION_PATH, config.AUDIT_LOG_PATH, config.IDEMPOTENCY_DB_PATH, config.OUTBOX_DB_PATH,
+		config.WEBHOOKS_DB_PATH, config.WEBHOOK_DELIVERY_LOG_PATH, config.JOBS_DB_PATH,
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			return fmt.Errorf("directory of %v is missing", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+var idempotencyMutex sync.Mutex
+
+func readIdempotencyRecords() ([]model.IdempotencyRecord, error) {
+	records := []model.IdempotencyRecord{}
+
+	fileBytes, err := os.ReadFile(config.IDEMPOTENCY_DB_PATH)
+	if os.IsNotExist(err) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(fileBytes, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func commitIdempotencyRecords(records []model.IdempotencyRecord) error {
+	recordsBytes, err := json.MarshalIndent(records, "", "	")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.IDEMPOTENCY_DB_PATH, recordsBytes, 0644)
+}
+
+// ReserveIdempotencyKey returns the stored record if the key was already used by the caller and did not expire.
+// Otherwise the record is stored as in progress and nil is returned. Expired records are dropped on the way,
+// in progress records expire soon, so the key of a request which never completed, e.g. because of a crash,
+// can be used again.
+func ReserveIdempotencyKey(record model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	idempotencyMutex.Lock()
+	defer idempotencyMutex.Unlock()
+
+	records, err := readIdempotencyRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	activeRecords := []model.IdempotencyRecord{}
+	for _, stored := range records {
+		expiresAt, parseErr := time.Parse(time.RFC3339, stored.ExpiresAt)
+		if parseErr == nil && expiresAt.Before(now) {
+			continue
+		}
+		if stored.Scope == record.Scope && stored.Key == record.Key {
+			return &stored, nil
+		}
+		activeRecords = append(activeRecords, stored)
+	}
+
+	record.Status = model.IdempotencyInProgress
+	activeRecords = append(activeRecords, record)
+	return nil, commitIdempotencyRecords(activeRecords)
+}
+
+// CompleteIdempotencyKey stores the response of the record, which is replayed for the later requests with
+// the same key until the record expires.
+func CompleteIdempotencyKey(completed model.IdempotencyRecord) error {
+	idempotencyMutex.Lock()
+	defer idempotencyMutex.Unlock()
+
+	records, err := readIdempotencyRecords()
+	if err != nil {
+		return err
+	}
+
+	for recordIndex, record := range records {
+		if record.Scope == completed.Scope && record.Key == completed.Key {
+			record.Status = model.IdempotencyCompleted
+			record.ResponseCode = completed.ResponseCode
+			record.ContentType = completed.ContentType
+			record.ResponseBody = completed.ResponseBody
+			record.ExpiresAt = completed.ExpiresAt
+			records[recordIndex] = record
+			return commitIdempotencyRecords(records)
+		}
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets the key of the caller, so the request can be retried with it.
+func ReleaseIdempotencyKey(scope string, key string) error {
+	idempotencyMutex.Lock()
+	defer idempotencyMutex.Unlock()
+
+	records, err := readIdempotencyRecords()
+	if err != nil {
+		return err
+	}
+
+	for recordIndex, record := range records {
+		if record.Scope == scope && record.Key == key {
+			records = append(records[:recordIndex], records[recordIndex+1:]...)
+			return commitIdempotencyRecords(records)
+		}
+	}
+
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys drops the records which expired before now and returns how many.
+func PurgeExpiredIdempotencyKeys(now time.Time) (int, error) {
+	idempotencyMutex.Lock()
+	defer idempotencyMutex.Unlock()
+
+	records, err := readIdempotencyRecords()
+	if err != nil {
+		return 0, err
+	}
+
+	activeRecords := []model.IdempotencyRecord{}
+	for _, record := range records {
+		expiresAt, parseErr := time.Parse(time.RFC3339, record.ExpiresAt)
+		if parseErr != nil || !expiresAt.Before(now) {
+			activeRecords = append(activeRecords, record)
+		}
+	}
+
+	purged := len(records) - len(activeRecords)
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, commitIdempotencyRecords(activeRecords)
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// JobsCollection holds the job records next to the leases, so every replica sees the same runs.
+// Without it the records are kept in JOBS_DB_PATH, which is only shared within a single process.
+var JobsCollection *mongo.Collection
+
+var jobsMutex sync.Mutex
+
+// ErrJobNotFound is returned when no job with the given name was registered.
+var ErrJobNotFound = errors.New("no such job")
+
+func readJobs() ([]model.JobRecord, error) {
+	jobs := []model.JobRecord{}
+
+	fileBytes, err := os.ReadFile(config.JOBS_DB_PATH)
+	if os.IsNotExist(err) {
+		return jobs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func commitJobs(jobs []model.JobRecord) error {
+	jobsBytes, err := json.MarshalIndent(jobs, "", "	")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := config.JOBS_DB_PATH + ".tmp"
+	if err := os.WriteFile(tmpPath, jobsBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, config.JOBS_DB_PATH)
+}
+
+// ReadJobs returns the records of all registered jobs.
+func ReadJobs() ([]model.JobRecord, error) {
+	if JobsCollection != nil {
+		jobs := []model.JobRecord{}
+		cursor, err := JobsCollection.Find(ctx, bson.D{})
+		if err != nil {
+			return nil, err
+		}
+		return jobs, cursor.All(ctx, &jobs)
+	}
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	return readJobs()
+}
+
+// GetJob returns the record of the job.
+func GetJob(name string) (model.JobRecord, error) {
+	jobs, err := ReadJobs()
+	if err != nil {
+		return model.JobRecord{}, err
+	}
+	for _, job := range jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return model.JobRecord{}, ErrJobNotFound
+}
+
+// UpdateJob changes the record of the job in place, update gets a new record when create is set and the
+// job is unknown. Nothing is stored when update returns an error, the error is returned as is.
+func UpdateJob(name string, create bool, update func(job *model.JobRecord) error) (model.JobRecord, error) {
+	if JobsCollection != nil {
+		return updateStoredJob(name, create, update)
+	}
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	jobs, err := readJobs()
+	if err != nil {
+		return model.JobRecord{}, err
+	}
+
+	jobIndex := -1
+	for index, job := range jobs {
+		if job.Name == name {
+			jobIndex = index
+		}
+	}
+	if jobIndex == -1 && !create {
+		return model.JobRecord{}, ErrJobNotFound
+	}
+
+	job := model.JobRecord{Name: name, Status: model.JobScheduled}
+	if jobIndex != -1 {
+		job = jobs[jobIndex]
+	}
+	if err := update(&job); err != nil {
+		return job, err
+	}
+
+	if jobIndex == -1 {
+		jobs = append(jobs, job)
+	} else {
+		jobs[jobIndex] = job
+	}
+	return job, commitJobs(jobs)
+}
+
+// updateStoredJob is UpdateJob on the jobs collection. The record is only replaced when no other
+// replica changed it since it was read, otherwise the update runs again on the current record.
+func updateStoredJob(name string, create bool, update func(job *model.JobRecord) error) (model.JobRecord, error) {
+	for {
+		job := model.JobRecord{Name: name, Status: model.JobScheduled}
+		err := JobsCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: name}}).Decode(&job)
+		exists := err == nil
+		if errors.Is(err, mongo.ErrNoDocuments) && !create {
+			return model.JobRecord{}, ErrJobNotFound
+		} else if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return model.JobRecord{}, err
+		}
+
+		version := job.Version
+		if err := update(&job); err != nil {
+			return job, err
+		}
+		job.Name = name
+		job.Version = version + 1
+
+		if !exists {
+			_, err := JobsCollection.InsertOne(ctx, job)
+			if mongo.IsDuplicateKeyError(err) {
+				// another replica registered the job meanwhile
+				continue
+			}
+			return job, err
+		}
+		result, err := JobsCollection.ReplaceOne(ctx, bson.D{
+			primitive.E{Key: "_id", Value: name},
+			primitive.E{Key: "version", Value: version},
+		}, job)
+		if err != nil {
+			return job, err
+		}
+		if result.MatchedCount == 1 {
+			return job, nil
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var LeasesCollection *mongo.Collection
+
+// AcquireLease takes or renews the named lease for holder until now+ttl. It fails when another
+// holder has a lease which did not expire yet, the replicas share the leases through Mongo.
+func AcquireLease(name string, holder string, ttl time.Duration) (bool, error) {
+	if LeasesCollection == nil {
+		return false, errors.New("leases collection is not initialized")
+	}
+
+	now := time.Now()
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: name},
+		primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "holder", Value: holder}},
+			bson.D{primitive.E{Key: "expires_at", Value: bson.D{primitive.E{Key: "$lt", Value: now}}}},
+		}},
+	}
+	update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "holder", Value: holder},
+		primitive.E{Key: "expires_at", Value: now.Add(ttl)},
+	}}}
+
+	_, err := LeasesCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// the lease exists and belongs to someone else, so the upsert tried to insert it again
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
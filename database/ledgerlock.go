@@ -0,0 +1,30 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"fmt"
+	"os"
+)
+
+// lockLedger locks the event log and its projections, the conferences and the projection position,
+// against the other goroutines and, with a lock on the EVENT_LOG_PATH.lock file, against other
+// processes, e.g. bookingctl running next to the server. The returned function releases both.
+func lockLedger() (func(), error) {
+	ledgerMutex.Lock()
+	lockFile, err := os.OpenFile(config.EVENT_LOG_PATH+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		ledgerMutex.Unlock()
+		return nil, fmt.Errorf("cannot open the ledger lock: %v", err)
+	}
+	if err := flockExclusive(lockFile); err != nil {
+		lockFile.Close()
+		ledgerMutex.Unlock()
+		return nil, fmt.Errorf("cannot lock the ledger: %v", err)
+	}
+
+	return func() {
+		// closing the file releases the lock
+		lockFile.Close()
+		ledgerMutex.Unlock()
+	}, nil
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package database
+
+import (
+	"booking-webapp/config"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerLockWaitsForOtherProcesses(t *testing.T) {
+	useTestLedger(t)
+
+	// a lock through another file descriptor behaves like the lock of another process
+	otherProcess, err := os.OpenFile(config.EVENT_LOG_PATH+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, flockExclusive(otherProcess))
+
+	locked := make(chan func())
+	go func() {
+		unlock, err := lockLedger()
+		assert.NoError(t, err)
+		locked <- unlock
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("ledger locked while another process holds the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	otherProcess.Close()
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(time.Second):
+		t.Fatal("ledger not locked after the other process released the lock")
+	}
+}
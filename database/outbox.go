@@ -0,0 +1,164 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var outboxMutex sync.Mutex
+
+// ErrOutboxMessageNotFound is returned when the outbox has no message with the given id.
+var ErrOutboxMessageNotFound = errors.New("no such outbox message")
+
+func readOutbox() (model.Outbox, bool, error) {
+	outbox := model.Outbox{Messages: []model.OutboxMessage{}}
+
+	fileBytes, err := os.ReadFile(config.OUTBOX_DB_PATH)
+	if os.IsNotExist(err) {
+		return outbox, false, nil
+	} else if err != nil {
+		return outbox, false, err
+	}
+
+	if err := json.Unmarshal(fileBytes, &outbox); err != nil {
+		return outbox, false, err
+	}
+	return outbox, true, nil
+}
+
+// commitOutbox replaces the outbox file through a rename, so cursor and messages always change together.
+func commitOutbox(outbox model.Outbox) error {
+	outboxBytes, err := json.MarshalIndent(outbox, "", "	")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := config.OUTBOX_DB_PATH + ".tmp"
+	if err := os.WriteFile(tmpPath, outboxBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, config.OUTBOX_DB_PATH)
+}
+
+// ReadOutbox returns the undelivered messages.
+func ReadOutbox() (model.Outbox, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	outbox, _, err := readOutbox()
+	return outbox, err
+}
+
+// InitOutbox starts the outbox at the given event if there is none yet, so the events recorded
+// before the outbox existed do not cause side effects. It returns the cursor of the outbox.
+func InitOutbox(cursor uint64) (uint64, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	outbox, exists, err := readOutbox()
+	if err != nil || exists {
+		return outbox.Cursor, err
+	}
+	outbox.Cursor = cursor
+	return cursor, commitOutbox(outbox)
+}
+
+// EnqueueOutboxMessages adds the messages of the events up to cursor. Messages which are
+// already in the outbox are skipped, so the events can be looked at again after a crash.
+func EnqueueOutboxMessages(cursor uint64, messages []model.OutboxMessage) error {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	outbox, _, err := readOutbox()
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if _, found := findOutboxMessage(outbox, message.Id); !found {
+			outbox.Messages = append(outbox.Messages, message)
+		}
+	}
+	if cursor > outbox.Cursor {
+		outbox.Cursor = cursor
+	}
+	return commitOutbox(outbox)
+}
+
+// UpdateOutboxMessage stores the delivery state of the message, delivered messages are removed.
+func UpdateOutboxMessage(message model.OutboxMessage, delivered bool) error {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	outbox, _, err := readOutbox()
+	if err != nil {
+		return err
+	}
+
+	messageIndex, found := findOutboxMessage(outbox, message.Id)
+	if !found {
+		return fmt.Errorf("%w: %v", ErrOutboxMessageNotFound, message.Id)
+	}
+	if delivered {
+		outbox.Messages = append(outbox.Messages[:messageIndex], outbox.Messages[messageIndex+1:]...)
+	} else {
+		outbox.Messages[messageIndex] = message
+	}
+	return commitOutbox(outbox)
+}
+
+// ReplayOutboxMessages makes the messages pending again with a fresh retry budget. With no ids every
+// dead message is replayed. The replayed messages are returned.
+func ReplayOutboxMessages(ids ...string) ([]model.OutboxMessage, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	outbox, _, err := readOutbox()
+	if err != nil {
+		return nil, err
+	}
+
+	replayIndexes := []int{}
+	if len(ids) == 0 {
+		for messageIndex, message := range outbox.Messages {
+			if message.Status == model.OutboxDead {
+				replayIndexes = append(replayIndexes, messageIndex)
+			}
+		}
+	}
+	for _, id := range ids {
+		messageIndex, found := findOutboxMessage(outbox, id)
+		if !found {
+			return nil, fmt.Errorf("%w: %v", ErrOutboxMessageNotFound, id)
+		}
+		replayIndexes = append(replayIndexes, messageIndex)
+	}
+
+	replayed := []model.OutboxMessage{}
+	for _, messageIndex := range replayIndexes {
+		message := outbox.Messages[messageIndex]
+		message.Status = model.OutboxPending
+		message.Attempts = 0
+		message.NextAttemptAt = ""
+		outbox.Messages[messageIndex] = message
+		replayed = append(replayed, message)
+	}
+	if len(replayed) == 0 {
+		return replayed, nil
+	}
+	return replayed, commitOutbox(outbox)
+}
+
+func findOutboxMessage(outbox model.Outbox, id string) (int, bool) {
+	for messageIndex, message := range outbox.Messages {
+		if message.Id == id {
+			return messageIndex, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateUser inserts a new user, the login has to be unused.
+func CreateUser(login string, hashedPassword string, role string) error {
+	count, err := UsersCollection.CountDocuments(ctx, bson.D{primitive.E{Key: "login", Value: login}})
+	if err != nil {
+		return fmt.Errorf("server side problem occured while reading user data from database: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("user with login %v already exists", login)
+	}
+
+	_, err = UsersCollection.InsertOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: primitive.NewObjectID()},
+		primitive.E{Key: "login", Value: login},
+		primitive.E{Key: "password_hash", Value: hashedPassword},
+		primitive.E{Key: "role", Value: role},
+	})
+	if err != nil {
+		return fmt.Errorf("server side problem occured while saving user data to database: %v", err)
+	}
+
+	return nil
+}
+
+// SetUserPassword replaces the password hash of an existing user.
+func SetUserPassword(login string, hashedPassword string) error {
+	result, err := UsersCollection.UpdateOne(ctx,
+		bson.D{primitive.E{Key: "login", Value: login}},
+		bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "password_hash", Value: hashedPassword}}}})
+	if err != nil {
+		return fmt.Errorf("server side problem occured while saving user data to database: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no user with login %v", login)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var webhooksMutex sync.Mutex
+var webhookDeliveriesMutex sync.Mutex
+
+// ErrWebhookNotFound is returned when there is no webhook with the given id.
+var ErrWebhookNotFound = errors.New("no such webhook")
+
+func readWebhooks() ([]model.Webhook, error) {
+	webhooks := []model.Webhook{}
+
+	fileBytes, err := os.ReadFile(config.WEBHOOKS_DB_PATH)
+	if os.IsNotExist(err) {
+		return webhooks, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(fileBytes, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func commitWebhooks(webhooks []model.Webhook) error {
+	webhooksBytes, err := json.MarshalIndent(webhooks, "", "	")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.WEBHOOKS_DB_PATH, webhooksBytes, 0600)
+}
+
+// ReadWebhooks returns every webhook including its secret.
+func ReadWebhooks() ([]model.Webhook, error) {
+	webhooksMutex.Lock()
+	defer webhooksMutex.Unlock()
+
+	return readWebhooks()
+}
+
+// GetWebhook returns the webhook including its secret.
+func GetWebhook(id string) (model.Webhook, error) {
+	webhooks, err := ReadWebhooks()
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.Id == id {
+			return webhook, nil
+		}
+	}
+	return model.Webhook{}, fmt.Errorf("%w: %v", ErrWebhookNotFound, id)
+}
+
+// SaveWebhook adds the webhook or replaces the one with the same id.
+func SaveWebhook(webhook model.Webhook) error {
+	webhooksMutex.Lock()
+	defer webhooksMutex.Unlock()
+
+	webhooks, err := readWebhooks()
+	if err != nil {
+		return err
+	}
+
+	for webhookIndex, stored := range webhooks {
+		if stored.Id == webhook.Id {
+			webhooks[webhookIndex] = webhook
+			return commitWebhooks(webhooks)
+		}
+	}
+	return commitWebhooks(append(webhooks, webhook))
+}
+
+// DeleteWebhook removes the webhook, its delivery log is kept.
+func DeleteWebhook(id string) error {
+	webhooksMutex.Lock()
+	defer webhooksMutex.Unlock()
+
+	webhooks, err := readWebhooks()
+	if err != nil {
+		return err
+	}
+
+	for webhookIndex, stored := range webhooks {
+		if stored.Id == id {
+			return commitWebhooks(append(webhooks[:webhookIndex], webhooks[webhookIndex+1:]...))
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrWebhookNotFound, id)
+}
+
+// AppendWebhookDelivery appends the attempt to the delivery log.
+func AppendWebhookDelivery(delivery model.WebhookDelivery) error {
+	deliveryBytes, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	webhookDeliveriesMutex.Lock()
+	defer webhookDeliveriesMutex.Unlock()
+
+	deliveriesFile, err := os.OpenFile(config.WEBHOOK_DELIVERY_LOG_PATH, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer deliveriesFile.Close()
+
+	_, err = deliveriesFile.Write(append(deliveryBytes, '\n'))
+	return err
+}
+
+// ReadWebhookDeliveries returns the latest attempts for the webhook, newest first. limit 0 returns all.
+func ReadWebhookDeliveries(webhookId string, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+
+	webhookDeliveriesMutex.Lock()
+	defer webhookDeliveriesMutex.Unlock()
+
+	deliveriesFile, err := os.Open(config.WEBHOOK_DELIVERY_LOG_PATH)
+	if os.IsNotExist(err) {
+		return deliveries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer deliveriesFile.Close()
+
+	scanner := bufio.NewScanner(deliveriesFile)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var delivery model.WebhookDelivery
+		if err := json.Unmarshal(scanner.Bytes(), &delivery); err != nil {
+			return nil, fmt.Errorf("broken delivery at line %v of the webhook delivery log: %v", lineNumber, err)
+		}
+		if delivery.WebhookId == webhookId {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for left, right := 0, len(deliveries)-1; left < right; left, right = left+1, right-1 {
+		deliveries[left], deliveries[right] = deliveries[right], deliveries[left]
+	}
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,15 @@
+package docs
+
+import (
+	_ "embed"
+)
+
+// OpenAPI is the OpenAPI 3 document describing every route of the service.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// SwaggerUI renders OpenAPI with Swagger UI loaded from a CDN.
+//
+//go:embed swagger.html
+var SwaggerUI []byte
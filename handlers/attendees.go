@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/xlsx"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type attendeeColumn struct {
+	title string
+	value func(booking model.Booking, ticket uint) interface{}
+}
+
+// attendeeColumns can be picked with the columns query parameter, ticket is the
+// number of the ticket within the booking and needs per_ticket=true.
+var attendeeColumns = map[string]attendeeColumn{
+	"customer_name": {"Customer name", func(booking model.Booking, _ uint) interface{} { return booking.CustomerName }},
+	"ticket": {"Ticket", func(booking model.Booking, ticket uint) interface{} {
+		return fmt.Sprintf("%v of %v", ticket, booking.TicketsBooked)
+	}},
+	"tickets_booked": {"Tickets", func(booking model.Booking, _ uint) interface{} { return booking.TicketsBooked }},
+	"booking_id":     {"Booking ID", func(booking model.Booking, _ uint) interface{} { return booking.Id }},
+	"booked_at":      {"Booked at", func(booking model.Booking, _ uint) interface{} { return booking.BookedAt }},
+	"updated_at":     {"Updated at", func(booking model.Booking, _ uint) interface{} { return booking.UpdatedAt }},
+}
+
+var defaultAttendeeColumns = []string{"customer_name", "tickets_booked", "booking_id", "booked_at"}
+var defaultPerTicketColumns = []string{"customer_name", "ticket", "booking_id", "booked_at"}
+
+var fileNameUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+func GetAttendeesCSV(c *fiber.Ctx) error {
+	return sendAttendees(c, "csv")
+}
+
+func GetAttendeesXLSX(c *fiber.Ctx) error {
+	return sendAttendees(c, "xlsx")
+}
+
+// sendAttendees sends the active bookings of the conference sorted by customer name,
+// one row per booking or with per_ticket=true one row per ticket.
+func sendAttendees(c *fiber.Ctx, format string) error {
+	if !hasRole(c, model.RoleAdmin, model.RoleOrganizer) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	perTicket := c.Query("per_ticket") == "true"
+	columns := append([]string{}, defaultAttendeeColumns...)
+	if perTicket {
+		columns = append([]string{}, defaultPerTicketColumns...)
+	}
+	if c.Query("columns") != "" {
+		columns = strings.Split(c.Query("columns"), ",")
+	}
+	for index, column := range columns {
+		columns[index] = strings.TrimSpace(column)
+		if _, ok := attendeeColumns[columns[index]]; !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "incorrect input for attendee list columns",
+				"data":    fmt.Sprintf("unknown column %q, supported columns are customer_name, tickets_booked, booking_id, booked_at, updated_at and ticket", column)})
+		}
+		if columns[index] == "ticket" && !perTicket {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "incorrect input for attendee list columns",
+				"data":    "ticket column is only available with per_ticket=true"})
+		}
+	}
+
+	conference, geterr := database.GetConference(c.Params("confId"))
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
+	}
+
+	rows := [][]interface{}{{}}
+	for _, column := range columns {
+		rows[0] = append(rows[0], attendeeColumns[column].title)
+	}
+	for _, booking := range activeBookings(conference) {
+		tickets := uint(1)
+		if perTicket {
+			tickets = booking.TicketsBooked
+		}
+		for ticket := uint(1); ticket <= tickets; ticket++ {
+			row := make([]interface{}, 0, len(columns))
+			for _, column := range columns {
+				row = append(row, attendeeColumns[column].value(booking, ticket))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	var out bytes.Buffer
+	var writeerr error
+	if format == "xlsx" {
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		writeerr = xlsx.Write(&out, conference.ConferenceName, rows)
+	} else {
+		c.Set(fiber.HeaderContentType, "text/csv")
+		writeerr = writeCSV(&out, rows)
+	}
+	if writeerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending attendee list to client",
+			"data":    fmt.Sprint(writeerr)})
+	}
+
+	fileName := strings.Trim(fileNameUnsafeChars.ReplaceAllString(conference.ConferenceName, "-"), "-")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%v-attendees.%v"`, strings.ToLower(fileName), format))
+	return c.Send(out.Bytes())
+}
+
+func activeBookings(conference model.Conference) []model.Booking {
+	bookings := []model.Booking{}
+	for _, booking := range conference.Bookings {
+		if !booking.IsCanceled {
+			bookings = append(bookings, booking)
+		}
+	}
+
+	sort.SliceStable(bookings, func(a, b int) bool {
+		return strings.ToLower(bookings[a].CustomerName) < strings.ToLower(bookings[b].CustomerName)
+	})
+	return bookings
+}
+
+// formulaPrefixes start a formula when a spreadsheet opens the CSV file.
+const formulaPrefixes = "=+-@\t\r"
+
+func writeCSV(out *bytes.Buffer, rows [][]interface{}) error {
+	writer := csv.NewWriter(out)
+	for _, row := range rows {
+		record := make([]string, 0, len(row))
+		for _, value := range row {
+			cell := fmt.Sprint(value)
+			// customer names are user input, e.g. =HYPERLINK(...) must stay text
+			if _, isText := value.(string); isText && cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+				cell = "'" + cell
+			}
+			record = append(record, cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
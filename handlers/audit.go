@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	auditEntityConference = "conference"
+	auditEntityBooking    = "booking"
+
+	auditConferenceCreated = "conference.created"
+	auditConferenceUpdated = "conference.updated"
+	auditConferenceDeleted = "conference.deleted"
+	auditBookingCreated    = "booking.created"
+	auditBookingUpdated    = "booking.updated"
+	auditBookingCanceled   = "booking.canceled"
+)
+
+func GetAuditLog(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	filter := database.AuditFilter{
+		Entity:       c.Query("entity"),
+		EntityId:     c.Query("entity_id"),
+		ConferenceId: c.Query("conference_id"),
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+	}
+	for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if c.Query(param) == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, c.Query(param))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "incorrect input for audit log filter",
+				"data":    param + " has to be RFC 3339 timestamp, e.g. 2022-11-02T16:29:20+03:00"})
+		}
+		*bound = parsed
+	}
+
+	return sendAuditEntries(c, filter)
+}
+
+func GetConferenceHistory(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	return sendAuditEntries(c, database.AuditFilter{ConferenceId: c.Params("id")})
+}
+
+func sendAuditEntries(c *fiber.Ctx, filter database.AuditFilter) error {
+	entries, readerr := database.ReadAuditEntries(filter)
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading audit log",
+			"data":    readerr})
+	}
+
+	entriesJson, err := json.MarshalIndent(entries, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending audit log to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(entriesJson))
+}
+
+// recordAudit appends the change to the audit log. before and after are pointers to the entity versions,
+// nil for created or deleted entities. The change is already committed, so failures are only logged.
+func recordAudit(c *fiber.Ctx, action string, entity string, entityId string, confId string, before interface{}, after interface{}) {
+	actor, actorRole := requestActor(c)
+	requestId, _ := c.Locals("requestid").(string)
+	newUuid, _ := uuid.NewRandom()
+
+	entry := model.AuditEntry{
+		Id:           strings.Replace(newUuid.String(), "-", "", -1),
+		Timestamp:    time.Now().Format(time.RFC3339),
+		RequestId:    requestId,
+		Actor:        actor,
+		ActorRole:    actorRole,
+		Action:       action,
+		Entity:       entity,
+		EntityId:     entityId,
+		ConferenceId: confId,
+		Changes:      database.AuditChanges(before, after, "bookings"),
+	}
+
+	if err := database.AppendAuditEntry(entry); err != nil {
+		logging.For(c).Error("cannot append audit log entry", "action", action, "entity_id", entityId, "error", err)
+	}
+}
+
+// requestActor returns username and role from the JWT of the request, anonymous if there is none.
+func requestActor(c *fiber.Ctx) (string, string) {
+	token, ok := c.Locals("identity").(*jwt.Token)
+	if !ok {
+		return "anonymous", "anonymous"
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	username, _ := claims["username"].(string)
+	role, _ := claims["role"].(string)
+	return username, role
+}
@@ -3,11 +3,10 @@ package handlers
 import (
 	"booking-webapp/database"
 	"booking-webapp/model"
+	"booking-webapp/validation"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -22,11 +21,8 @@ func GetBookings(c *fiber.Ctx) error {
 	}
 
 	conference, geterr := database.GetConference(c.Params("confId"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading bokings info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
 	bookingsJson, err := json.MarshalIndent(conference.Bookings, "", "	")
@@ -40,17 +36,20 @@ func GetBookings(c *fiber.Ctx) error {
 	return c.SendString(string(bookingsJson))
 }
 
+// GetBooking returns the booking to everyone knowing its id. Customer email and check-ins
+// are only shown to admins and organizers.
 func GetBooking(c *fiber.Ctx) error {
 	conference, geterr := database.GetConference(c.Params("confId"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading bookings info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
 	for _, booking := range conference.Bookings {
 		if booking.Id == c.Params("bookingId") {
+			if !hasRole(c, model.RoleAdmin, model.RoleOrganizer) {
+				booking.CustomerEmail = ""
+				booking.CheckIns = nil
+			}
 			bookingJson, err := json.MarshalIndent(booking, "", "	")
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -70,57 +69,42 @@ func GetBooking(c *fiber.Ctx) error {
 }
 
 func CreateBooking(c *fiber.Ctx) error {
-	newBooking := new(model.Booking)
-
-	if err := c.BodyParser(newBooking); err != nil {
+	req := new(model.BookingRequest)
+	if err := c.BodyParser(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for booking parameters",
 			"data":    err})
 	}
-	newBooking.CustomerName = strings.TrimSpace(newBooking.CustomerName)
-
-	newUuid, _ := uuid.NewRandom()
-	newBooking.Id = strings.Replace(newUuid.String(), "-", "", -1)
-	currentTime := time.Now().Format(time.RFC3339)
-
-	newBooking.BookedAt = currentTime
-	newBooking.UpdatedAt = currentTime
-	newBooking.IsCanceled = false
+	trimSpace(req.CustomerName)
 
 	conference, geterr := database.GetConference(c.Params("confId"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading bookings info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
-	customerNameValidation := customerNameValidation(newBooking.CustomerName)
-	numberOfTicketsValidation := ticketsNumberValidation(newBooking.TicketsBooked, conference.RemainingTickets)
-
-	if customerNameValidation != nil {
+	if validationErrs := validation.Booking(req, conference, nil, false); len(validationErrs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for booking parameters",
-			"data":    fmt.Sprint(customerNameValidation)})
+			"data":    validationErrs})
 	}
-	if numberOfTicketsValidation != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "incorrect input for booking parameters",
-			"data":    fmt.Sprint(numberOfTicketsValidation)})
+
+	newUuid, _ := uuid.NewRandom()
+	event := newEvent(c, model.EventBookingCreated, conference.Id)
+	event.BookingId = strings.Replace(newUuid.String(), "-", "", -1)
+	event.CustomerName = *req.CustomerName
+	event.TicketsBooked = *req.TicketsBooked
+	if req.CustomerEmail != nil {
+		event.CustomerEmail = *req.CustomerEmail
 	}
 
-	conference.RemainingTickets = conference.RemainingTickets - newBooking.TicketsBooked
-	conference.Bookings = append(conference.Bookings, *newBooking)
-	commiterr := database.CommitConferenceToLocalDB(conference)
+	conference, commiterr := database.ApplyEvents(event)
 	if commiterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while saving booking info to the database",
-			"data":    commiterr})
+		return ledgerError(c, commiterr, "server side problem occured while saving booking info to the database")
 	}
+	newBooking := bookingById(conference, event.BookingId)
+	recordAudit(c, auditBookingCreated, auditEntityBooking, newBooking.Id, conference.Id, nil, &newBooking)
 
 	newBookingJson, err := json.MarshalIndent(newBooking, "", "	")
 	if err != nil {
@@ -134,12 +118,26 @@ func CreateBooking(c *fiber.Ctx) error {
 }
 
 func UpdateBooking(c *fiber.Ctx) error {
+	return updateBooking(c, replaceUpdate)
+}
+
+// PatchBooking applies a JSON Merge Patch to the booking, only the supplied fields are validated and updated.
+func PatchBooking(c *fiber.Ctx) error {
+	return updateBooking(c, mergePatchUpdate("customer_name", "tickets_booked", "customer_email"))
+}
+
+func PatchBookingName(c *fiber.Ctx) error {
+	return updateBooking(c, fieldUpdate("customer_name"))
+}
+
+func PatchBookingTickets(c *fiber.Ctx) error {
+	return updateBooking(c, fieldUpdate("tickets_booked"))
+}
+
+func updateBooking(c *fiber.Ctx, mode updateMode) error {
 	conference, geterr := database.GetConference(c.Params("confId"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading bookings info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
 	var booking model.Booking = model.Booking{}
@@ -164,39 +162,59 @@ func UpdateBooking(c *fiber.Ctx) error {
 			"data":    fmt.Errorf("no booking with id %v for conference id %v", c.Params("bookingId"), c.Params("confId"))})
 	}
 
-	updatedBooking := new(model.Booking)
-
-	if err := c.BodyParser(updatedBooking); err != nil {
+	req := new(model.BookingRequest)
+	validationErrs := validation.Errors{}
+	if mode.isPartial() {
+		patchErrs, err := readUpdate(c, req, mode)
+		if err != nil {
+			return patchError(c, err, "incorrect input for booking parameters")
+		}
+		validationErrs = patchErrs
+	} else if err := c.BodyParser(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for booking parameters",
 			"data":    err})
 	}
-	updatedBooking.CustomerName = strings.TrimSpace(updatedBooking.CustomerName)
-
-	reqPathParts := strings.Split(c.OriginalURL(), "/")
-	var validationErr error = nil
-	// adjust validation according to the request path, e.g. do only name validation if name update occure
-	if reqPathParts[len(reqPathParts)-1] == "name" {
-		updatedBooking.TicketsBooked = booking.TicketsBooked
-		validationErr = customerNameValidation(updatedBooking.CustomerName)
-	} else if reqPathParts[len(reqPathParts)-1] == "tickets" {
-		updatedBooking.CustomerName = booking.CustomerName
-		validationErr = ticketsNumberValidation(updatedBooking.TicketsBooked, conference.RemainingTickets)
-	} else {
-		validationErr = validateBookingInfoInput(*updatedBooking, conference.RemainingTickets)
-	}
-	if validationErr != nil {
+	trimSpace(req.CustomerName)
+
+	validationErrs = append(validationErrs, validation.Booking(req, conference, &booking, mode.isPartial())...)
+	if len(validationErrs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for booking parameters",
-			"data":    fmt.Sprint(validationErr)})
+			"data":    validationErrs})
+	}
+
+	events := []model.Event{}
+	if req.CustomerName != nil && *req.CustomerName != booking.CustomerName {
+		event := newEvent(c, model.EventCustomerNameChanged, conference.Id)
+		event.BookingId = booking.Id
+		event.CustomerName = *req.CustomerName
+		events = append(events, event)
+	}
+	if req.TicketsBooked != nil && *req.TicketsBooked != booking.TicketsBooked {
+		event := newEvent(c, model.EventTicketsChanged, conference.Id)
+		event.BookingId = booking.Id
+		event.TicketsBooked = *req.TicketsBooked
+		events = append(events, event)
+	}
+	if req.CustomerEmail != nil && *req.CustomerEmail != booking.CustomerEmail {
+		event := newEvent(c, model.EventCustomerEmailChanged, conference.Id)
+		event.BookingId = booking.Id
+		event.CustomerEmail = *req.CustomerEmail
+		events = append(events, event)
 	}
 
-	updatedBooking.Id = booking.Id
-	updatedBooking.BookedAt = booking.BookedAt
-	updatedBooking.UpdatedAt = time.Now().Format(time.RFC3339)
-	updatedBooking.IsCanceled = booking.IsCanceled
+	updatedBooking := booking
+	if len(events) > 0 {
+		updatedConf, commiterr := database.ApplyEvents(events...)
+		if commiterr != nil {
+			return ledgerError(c, commiterr, "server side problem occured while saving booking info to the database")
+		}
+		updatedBooking = bookingById(updatedConf, booking.Id)
+		recordAudit(c, auditBookingUpdated, auditEntityBooking, booking.Id, conference.Id, &booking, &updatedBooking)
+	}
 
 	updatedBookingJson, err := json.MarshalIndent(updatedBooking, "", "	")
 	if err != nil {
@@ -206,43 +224,27 @@ func UpdateBooking(c *fiber.Ctx) error {
 			"data":    err})
 	}
 
-	conference.Bookings[bookingIndex] = *updatedBooking
-	conference.RemainingTickets = conference.TotalTickets - database.GetTotalBookings(conference)
-	commiterr := database.CommitConferenceToLocalDB(conference)
-	if commiterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while saving booking info to the database",
-			"data":    commiterr})
-	}
-
 	return c.SendString(string(updatedBookingJson))
 }
 
 func CancelBooking(c *fiber.Ctx) error {
 	conference, geterr := database.GetConference(c.Params("confId"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading bookings info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
-	for bookingIndex, booking := range conference.Bookings {
+	for _, booking := range conference.Bookings {
 		if booking.Id == c.Params("bookingId") && !booking.IsCanceled {
-			booking.UpdatedAt = time.Now().Format(time.RFC3339)
-			booking.IsCanceled = true
-			conference.RemainingTickets += booking.TicketsBooked
-			conference.Bookings[bookingIndex] = booking
-			commiterr := database.CommitConferenceToLocalDB(conference)
+			event := newEvent(c, model.EventBookingCanceled, conference.Id)
+			event.BookingId = booking.Id
+			updatedConf, commiterr := database.ApplyEvents(event)
 			if commiterr != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"status":  "error",
-					"message": "server side problem occured while saving booking info to the database",
-					"data":    commiterr})
+				return ledgerError(c, commiterr, "server side problem occured while saving booking info to the database")
 			}
+			canceledBooking := bookingById(updatedConf, booking.Id)
+			recordAudit(c, auditBookingCanceled, auditEntityBooking, booking.Id, conference.Id, &booking, &canceledBooking)
 
-			bookingJson, err := json.MarshalIndent(booking, "", "	")
+			bookingJson, err := json.MarshalIndent(canceledBooking, "", "	")
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"status":  "error",
@@ -264,34 +266,3 @@ func CancelBooking(c *fiber.Ctx) error {
 		"message": "booking not found",
 		"data":    fmt.Errorf("no booking with id %v for conference id %v", c.Params("bookingId"), c.Params("confId"))})
 }
-
-func validateBookingInfoInput(booking model.Booking, remainingTickets uint) error {
-	nameValidationErr := customerNameValidation(booking.CustomerName)
-	ticketsValidationErr := ticketsNumberValidation(booking.TicketsBooked, remainingTickets)
-	if nameValidationErr != nil {
-		return nameValidationErr
-	}
-	if ticketsValidationErr != nil {
-		return ticketsValidationErr
-	}
-
-	return nil
-}
-
-func customerNameValidation(customerName string) error {
-	if len(customerName) < 2 {
-		return errors.New("name is too short")
-	} else if !strings.Contains(customerName, " ") {
-		return errors.New("last name is missing, try format 'firstName LastName'")
-	}
-	return nil
-}
-
-func ticketsNumberValidation(ticketsToBook uint, remainingTickets uint) error {
-	if ticketsToBook == 0 {
-		return errors.New("cannot book 0 tickets")
-	} else if ticketsToBook > remainingTickets {
-		return fmt.Errorf("only %v tickets left for the conference, overbooking is not supported", remainingTickets)
-	}
-	return nil
-}
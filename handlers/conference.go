@@ -3,8 +3,8 @@ package handlers
 import (
 	"booking-webapp/database"
 	"booking-webapp/model"
+	"booking-webapp/validation"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 
@@ -39,11 +39,8 @@ func GetConferences(c *fiber.Ctx) error {
 
 func GetConference(c *fiber.Ctx) error {
 	conference, geterr := database.GetConference(c.Params("id"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "server side problem occured while reading conference info from database",
-			"data":    geterr})
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
 	}
 
 	if !isAdminRole(c) {
@@ -68,27 +65,43 @@ func CreateNewConference(c *fiber.Ctx) error {
 			"data":    nil})
 	}
 
-	newConf := new(model.Conference)
-	if err := c.BodyParser(newConf); err != nil {
+	req := new(model.ConferenceRequest)
+	if err := c.BodyParser(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for conferrence parameters",
 			"data":    err})
 	}
-	newConf.ConferenceName = strings.TrimSpace(newConf.ConferenceName)
+	trimSpace(req.ConferenceName)
+
+	conferences, readerr := database.ReadLocalDB()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading conferences info from database",
+			"data":    readerr})
+	}
 
-	validationErr := validateConferenceInfoInput(*newConf, true)
-	if validationErr != nil {
+	if validationErrs := validation.Conference(req, nil, conferences, false); len(validationErrs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for conferrence parameters",
-			"data":    fmt.Sprint(validationErr)})
+			"data":    validationErrs})
 	}
 
 	newUuid, _ := uuid.NewRandom()
-	newConf.Id = strings.Replace(newUuid.String(), "-", "", -1)
-	newConf.RemainingTickets = newConf.TotalTickets
-	newConf.Bookings = []model.Booking{}
+	event := newEvent(c, model.EventConferenceCreated, strings.Replace(newUuid.String(), "-", "", -1))
+	event.ConferenceName = *req.ConferenceName
+	event.TotalTickets = *req.TotalTickets
+	if req.StartsAt != nil {
+		event.StartsAt = *req.StartsAt
+	}
+
+	newConf, commiterr := database.ApplyEvents(event)
+	if commiterr != nil {
+		return ledgerError(c, commiterr, "error while saving transaction result to the database")
+	}
+	recordAudit(c, auditConferenceCreated, auditEntityConference, newConf.Id, newConf.Id, nil, &newConf)
 
 	newConfJson, err := json.MarshalIndent(newConf, "", "	")
 	if err != nil {
@@ -98,18 +111,27 @@ func CreateNewConference(c *fiber.Ctx) error {
 			"data":    err})
 	}
 
-	commiterr := database.CommitConferenceToLocalDB(*newConf)
-	if commiterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "error while saving transaction result to the database",
-			"data":    commiterr})
-	}
-
 	return c.SendString(string(newConfJson))
 }
 
 func UpdateConference(c *fiber.Ctx) error {
+	return updateConference(c, replaceUpdate)
+}
+
+// PatchConference applies a JSON Merge Patch to the conference, only the supplied fields are validated and updated.
+func PatchConference(c *fiber.Ctx) error {
+	return updateConference(c, mergePatchUpdate("conference_name", "total_tickets", "starts_at"))
+}
+
+func PatchConferenceName(c *fiber.Ctx) error {
+	return updateConference(c, fieldUpdate("conference_name"))
+}
+
+func PatchConferenceTickets(c *fiber.Ctx) error {
+	return updateConference(c, fieldUpdate("total_tickets"))
+}
+
+func updateConference(c *fiber.Ctx, mode updateMode) error {
 	if !isAdminRole(c) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  "error",
@@ -118,45 +140,68 @@ func UpdateConference(c *fiber.Ctx) error {
 	}
 
 	conference, geterr := database.GetConference(c.Params("id"))
-	if geterr := database.HandleGetConferenceError(geterr, c); geterr != nil {
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
+	}
+
+	conferences, readerr := database.ReadLocalDB()
+	if readerr != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
-			"message": "server side problem occured while reading conference info from database",
-			"data":    geterr})
+			"message": "server side problem occured while reading conferences info from database",
+			"data":    readerr})
 	}
 
-	updatedConf := new(model.Conference)
-
-	if err := c.BodyParser(updatedConf); err != nil {
+	req := new(model.ConferenceRequest)
+	validationErrs := validation.Errors{}
+	if mode.isPartial() {
+		patchErrs, err := readUpdate(c, req, mode)
+		if err != nil {
+			return patchError(c, err, "incorrect input for conferrence parameters")
+		}
+		validationErrs = patchErrs
+	} else if err := c.BodyParser(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for conferrence parameters",
 			"data":    err})
 	}
-	updatedConf.ConferenceName = strings.TrimSpace(updatedConf.ConferenceName)
-
-	reqPathParts := strings.Split(c.OriginalURL(), "/")
-	var validationErr error = nil
-	// adjust validation according to the request path, e.g. do only name validation if name update occure
-	if reqPathParts[len(reqPathParts)-1] == "name" {
-		updatedConf.TotalTickets = conference.TotalTickets
-		validationErr = isValidConferenceName(updatedConf.ConferenceName, false)
-	} else if reqPathParts[len(reqPathParts)-1] == "tickets" {
-		updatedConf.ConferenceName = conference.ConferenceName
-		validationErr = isValidConferenceTotalTickets(*updatedConf, false)
-	} else {
-		validationErr = validateConferenceInfoInput(*updatedConf, false)
-	}
-	if validationErr != nil {
+	trimSpace(req.ConferenceName)
+
+	validationErrs = append(validationErrs, validation.Conference(req, &conference, conferences, mode.isPartial())...)
+	if len(validationErrs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "incorrect input for conferrence parameters",
-			"data":    fmt.Sprint(validationErr)})
+			"data":    validationErrs})
+	}
+
+	events := []model.Event{}
+	if req.ConferenceName != nil && *req.ConferenceName != conference.ConferenceName {
+		event := newEvent(c, model.EventConferenceRenamed, conference.Id)
+		event.ConferenceName = *req.ConferenceName
+		events = append(events, event)
+	}
+	if req.TotalTickets != nil && *req.TotalTickets != conference.TotalTickets {
+		event := newEvent(c, model.EventCapacityChanged, conference.Id)
+		event.TotalTickets = *req.TotalTickets
+		events = append(events, event)
+	}
+	if req.StartsAt != nil && *req.StartsAt != conference.StartsAt {
+		event := newEvent(c, model.EventConferenceScheduled, conference.Id)
+		event.StartsAt = *req.StartsAt
+		events = append(events, event)
 	}
 
-	updatedConf.Id = conference.Id
-	updatedConf.RemainingTickets = updatedConf.TotalTickets - database.GetTotalBookings(conference)
-	updatedConf.Bookings = conference.Bookings
+	updatedConf := conference
+	if len(events) > 0 {
+		var commiterr error
+		updatedConf, commiterr = database.ApplyEvents(events...)
+		if commiterr != nil {
+			return ledgerError(c, commiterr, "error while saving transaction result to the database")
+		}
+		recordAudit(c, auditConferenceUpdated, auditEntityConference, conference.Id, conference.Id, &conference, &updatedConf)
+	}
 
 	updatedConfJson, err := json.MarshalIndent(updatedConf, "", "	")
 	if err != nil {
@@ -166,14 +211,6 @@ func UpdateConference(c *fiber.Ctx) error {
 			"data":    err})
 	}
 
-	commiterr := database.CommitConferenceToLocalDB(*updatedConf)
-	if commiterr != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "error while saving transaction result to the database",
-			"data":    commiterr})
-	}
-
 	return c.SendString(string(updatedConfJson))
 }
 
@@ -191,10 +228,13 @@ func DeleteConference(c *fiber.Ctx) error {
 	}
 	confId := c.Params("id")
 
-	for confIndex, conference := range conferences {
+	for _, conference := range conferences {
 		if conference.Id == confId {
-			conferences = append(conferences[:confIndex], conferences[confIndex+1:]...)
-			database.CommitConferencesToLocalDB(conferences)
+			_, commiterr := database.ApplyEvents(newEvent(c, model.EventConferenceDeleted, confId))
+			if commiterr != nil {
+				return ledgerError(c, commiterr, "error while saving transaction result to the database")
+			}
+			recordAudit(c, auditConferenceDeleted, auditEntityConference, confId, confId, &conference, nil)
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{
 				"status":  "success",
 				"message": "conference deleted",
@@ -208,66 +248,18 @@ func DeleteConference(c *fiber.Ctx) error {
 		"data":    fmt.Sprintf("no conference with id %v to delete", confId)})
 }
 
-func validateConferenceInfoInput(conf model.Conference, isNew bool) error {
-	nameValidationErr := isValidConferenceName(conf.ConferenceName, isNew)
-	totalTicketsValidationErr := isValidConferenceTotalTickets(conf, isNew)
-	if nameValidationErr != nil {
-		return fmt.Errorf("incorrect input for conferrence name: %v", nameValidationErr)
-	}
-	if totalTicketsValidationErr != nil {
-		return fmt.Errorf("incorrect input for conferrence total number of tickets: %v", totalTicketsValidationErr)
-	}
-
-	return nil
-}
-
-func isValidConferenceName(name string, isNew bool) error {
-	if len(name) < 2 {
-		return errors.New("conference name is too short")
-	} else if isNew {
-		nameExists, err := ifConferenceNameAlreadyExist(name)
-		if err != nil {
-			return err
-		}
-		if nameExists {
-			return errors.New("conference name already exist")
-		}
-	}
-	return nil
-}
-
-func ifConferenceNameAlreadyExist(name string) (bool, error) {
-	conferences, dbreaderr := database.ReadLocalDB()
-	if dbreaderr != nil {
-		return false, fmt.Errorf("server side problem occured while reading conferences info from database")
-	}
-
-	for _, conference := range conferences {
-		if conference.ConferenceName == name {
-			return true, nil
-		}
-	}
-	return false, nil
+func isAdminRole(c *fiber.Ctx) bool {
+	return hasRole(c, model.RoleAdmin)
 }
 
-func isValidConferenceTotalTickets(conf model.Conference, isNew bool) error {
-	if conf.TotalTickets == 0 {
-		return errors.New("conference cannot have zero tickets for distribution")
-	}
-	if !isNew {
-		totalBookings := database.GetTotalBookings(conf)
-		if conf.TotalTickets < totalBookings {
-			return fmt.Errorf("cannot assign %v as total tickets, %v tickets already booked", conf.TotalTickets, totalBookings)
-		}
-		return nil
+// hasRole reports whether the role of the authorized request is one of roles, requests without JWT have none.
+func hasRole(c *fiber.Ctx, roles ...string) bool {
+	token, ok := c.Locals("identity").(*jwt.Token)
+	if !ok {
+		return false
 	}
-	return nil
-}
-
-func isAdminRole(c *fiber.Ctx) bool {
-	token := c.Locals("identity").(*jwt.Token)
-	claims := token.Claims.(jwt.MapClaims)
-	return claims["role"].(string) == "admin"
+	role, _ := token.Claims.(jwt.MapClaims)["role"].(string)
+	return containsString(roles, role)
 }
 
 func cleanBookingsData(conferences []model.Conference) []model.Conference {
@@ -278,3 +270,9 @@ func cleanBookingsData(conferences []model.Conference) []model.Conference {
 
 	return conferences
 }
+
+func trimSpace(value *string) {
+	if value != nil {
+		*value = strings.TrimSpace(*value)
+	}
+}
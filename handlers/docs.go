@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"booking-webapp/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetOpenAPI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(docs.OpenAPI)
+}
+
+func GetDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docs.SwaggerUI)
+}
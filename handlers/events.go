@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newEvent starts a ledger event of the request, type specific fields are filled by the caller.
+func newEvent(c *fiber.Ctx, eventType string, confId string) model.Event {
+	requestId, _ := c.Locals("requestid").(string)
+	return model.Event{
+		Type:         eventType,
+		OccurredAt:   time.Now().Format(time.RFC3339),
+		RequestId:    requestId,
+		ConferenceId: confId,
+	}
+}
+
+// ledgerError responds with 409 when the change was rejected by the ledger, e.g. because a concurrent
+// booking took the remaining tickets, and with 500 for storage problems.
+func ledgerError(c *fiber.Ctx, err error, message string) error {
+	status := fiber.StatusInternalServerError
+	if errors.Is(err, database.ErrEventRejected) {
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    fmt.Sprint(err)})
+}
+
+func bookingById(conference model.Conference, bookingId string) model.Booking {
+	for _, booking := range conference.Bookings {
+		if booking.Id == bookingId {
+			return booking
+		}
+	}
+	return model.Booking{}
+}
+
+func RebuildProjections(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	replayedEvents, rebuilderr := database.RebuildProjections()
+	if rebuilderr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while rebuilding projections from the event log",
+			"data":    fmt.Sprint(rebuilderr)})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "projections rebuilt from the event log",
+		"data":    fiber.Map{"replayed_events": replayedEvents}})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/live"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// feedRequest is a message of the client, it replaces the conference filter.
+type feedRequest struct {
+	ConferenceIds []string `json:"conference_ids"`
+}
+
+// AcceptFeed lets admins and organizers upgrade to the activity feed WebSocket.
+func AcceptFeed(c *fiber.Ctx) error {
+	if !hasRole(c, model.RoleAdmin, model.RoleOrganizer) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"status":  "error",
+			"message": "the feed is served over WebSocket only",
+			"data":    nil})
+	}
+	return c.Next()
+}
+
+// Feed streams the booking and conference changes to the WebSocket as JSON messages. The conference_id
+// query parameter (comma separated) limits the feed to these conferences, the client changes the filter
+// by sending {"conference_ids": [...]}. Every filter is acknowledged with a subscribed message.
+// Slow clients get a dropped message instead of the changes they could not keep up with.
+func Feed(conn *websocket.Conn) {
+	settings := config.Current.Feed
+	subscription := live.Activity.Subscribe(settings.Buffer, splitConfIds(conn.Query("conference_id")))
+	defer subscription.Cancel()
+
+	acks := make(chan []string, 1)
+	closed := make(chan struct{})
+	go readFeedRequests(conn, subscription, settings, acks, closed)
+
+	write := func(message live.FeedMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(settings.WriteTimeout))
+		return conn.WriteJSON(message)
+	}
+	if err := write(live.FeedMessage{Type: live.FeedSubscribed, ConferenceIds: splitConfIds(conn.Query("conference_id"))}); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(settings.PingInterval)
+	defer ticker.Stop()
+	for {
+		var err error
+		select {
+		case message, open := <-subscription.Messages:
+			if !open {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(settings.WriteTimeout))
+				return
+			}
+			err = write(message)
+		case confIds := <-acks:
+			err = write(live.FeedMessage{Type: live.FeedSubscribed, ConferenceIds: confIds})
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(settings.WriteTimeout))
+		case <-closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readFeedRequests applies the filters sent by the client until the connection breaks.
+// A client which answers no ping within two intervals is disconnected.
+func readFeedRequests(conn *websocket.Conn, subscription *live.FeedSubscription, settings config.Feed, acks chan []string, closed chan<- struct{}) {
+	defer close(closed)
+
+	readTimeout := 2 * settings.PingInterval
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		var request feedRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			logging.Default.Debug("ignoring invalid feed request", "error", err)
+			continue
+		}
+		subscription.SetFilter(request.ConferenceIds)
+		select {
+		case <-acks:
+		default:
+		}
+		acks <- request.ConferenceIds
+	}
+}
+
+func splitConfIds(value string) []string {
+	confIds := []string{}
+	for _, confId := range strings.Split(value, ",") {
+		if confId = strings.TrimSpace(confId); confId != "" {
+			confIds = append(confIds, confId)
+		}
+	}
+	return confIds
+}
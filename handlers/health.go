@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/health"
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetHealthz is the liveness probe, it answers as long as the process serves requests.
+func GetHealthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "alive",
+		"data":    fiber.Map{"phase": health.Default.Phase()}})
+}
+
+// GetReadyz is the readiness probe. It runs the component checks, each for up to HEALTH_CHECK_TIMEOUT (2s),
+// and answers 503 while starting, draining on shutdown or while a component is down.
+func GetReadyz(c *fiber.Ctx) error {
+	checkCtx, cancel := context.WithTimeout(context.Background(), config.Current.Health.CheckTimeout)
+	defer cancel()
+
+	report := health.Default.Ready(checkCtx)
+	if report.Status != health.Up {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "not ready",
+			"data":    report})
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "ready",
+		"data":    report})
+}
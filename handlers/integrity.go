@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"booking-webapp/integrity"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CheckIntegrity(c *fiber.Ctx) error {
+	return integrityReport(c, integrity.Check)
+}
+
+func FixIntegrity(c *fiber.Ctx) error {
+	return integrityReport(c, integrity.Fix)
+}
+
+func integrityReport(c *fiber.Ctx, check func() (integrity.Report, error)) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	report, checkerr := check()
+	if checkerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while checking data integrity",
+			"data":    fmt.Sprint(checkerr)})
+	}
+
+	message := "no violations found"
+	if !report.IsHealthy() {
+		message = fmt.Sprintf("%v violations found", len(report.Violations))
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    report})
+}
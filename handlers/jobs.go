@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	auditEntityJob    = "job"
+	auditJobTriggered = "job.triggered"
+)
+
+// errJobRunning is returned when a job is triggered while it runs.
+var errJobRunning = errors.New("job is running, try again when it finished")
+
+// GetJobs lists the background jobs with their schedule and the outcome of the last run.
+func GetJobs(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	jobs, readerr := database.ReadJobs()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading jobs",
+			"data":    readerr})
+	}
+
+	return sendJobJson(c, jobs)
+}
+
+func GetJob(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	job, geterr := database.GetJob(c.Params("name"))
+	if geterr != nil {
+		return jobError(c, geterr, "server side problem occured while reading jobs")
+	}
+
+	return sendJobJson(c, job)
+}
+
+// RunJob schedules the job for the next poll of the scheduler, one-shot jobs which are done run again.
+func RunJob(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	var before model.JobRecord
+	job, updateerr := database.UpdateJob(c.Params("name"), false, func(job *model.JobRecord) error {
+		if job.Status == model.JobRunning {
+			return errJobRunning
+		}
+		before = *job
+		job.Status = model.JobScheduled
+		job.NextRunAt = time.Now().UTC().Format(time.RFC3339)
+		return nil
+	})
+	if updateerr != nil {
+		return jobError(c, updateerr, "server side problem occured while scheduling the job")
+	}
+	recordAudit(c, auditJobTriggered, auditEntityJob, job.Name, "", &before, &job)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "job scheduled",
+		"data":    job})
+}
+
+func jobError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "job not found",
+			"data":    err.Error()})
+	case errors.Is(err, errJobRunning):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "job is running",
+			"data":    err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    err})
+}
+
+func sendJobJson(c *fiber.Ctx, v interface{}) error {
+	jobJson, err := json.MarshalIndent(v, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending jobs to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(jobJson))
+}
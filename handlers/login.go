@@ -3,9 +3,10 @@ package handlers
 import (
 	"booking-webapp/config"
 	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/metrics"
 	"booking-webapp/model"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,6 +33,7 @@ func Login(c *fiber.Ctx) error {
 	if err == fiber.ErrUnprocessableEntity {
 		isAnonymousCall = true
 	} else if err != nil {
+		metrics.RecordLogin(metrics.LoginFailure, "invalid_request")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Error on login request when parse credentials",
@@ -42,12 +44,14 @@ func Login(c *fiber.Ctx) error {
 	claims := token.Claims.(jwt.MapClaims)
 	claims["exp"] = time.Now().Add(time.Hour * 8).Unix()
 
+	loginKind := "anonymous"
 	if isAnonymousCall {
 		claims["username"] = "anonymous"
 		claims["role"] = "anonymous"
 	} else {
-		user, autherr := authenticate(creds.Login, creds.Password)
+		user, reason, autherr := authenticate(creds.Login, creds.Password)
 		if autherr != nil {
+			metrics.RecordLogin(metrics.LoginFailure, reason)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "Error on login request when comparing user data",
@@ -56,31 +60,41 @@ func Login(c *fiber.Ctx) error {
 
 		claims["username"] = user.Login
 		claims["role"] = user.Role
+		loginKind = "password"
 	}
 
 	sign, enverr := config.GetSecret("SIGN")
 	if enverr != nil {
-		log.Print(enverr)
+		logging.For(c).Error("cannot sign token", "error", enverr)
+		metrics.RecordLogin(metrics.LoginFailure, "server_error")
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
 	t, err := token.SignedString([]byte(sign))
 	if err != nil {
+		metrics.RecordLogin(metrics.LoginFailure, "server_error")
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
+	metrics.RecordLogin(metrics.LoginSuccess, loginKind)
 	return c.JSON(fiber.Map{"status": "success", "message": "Success login", "data": t})
 }
 
-func authenticate(login string, pass string) (model.UserData, error) {
+// authenticate returns the user with the login and password. On failure the reason for the metrics is
+// returned, the error does not tell unknown users from wrong passwords.
+func authenticate(login string, pass string) (model.UserData, string, error) {
 	user, geterr := database.GetUserData(login)
 	if geterr != nil {
-		return model.UserData{}, fmt.Errorf("%v", geterr)
+		return model.UserData{}, "server_error", fmt.Errorf("%v", geterr)
 	}
 
 	if !isPasswordHashCorrect(user.HashedPassword, pass) {
-		return model.UserData{}, fmt.Errorf("invalid password")
+		reason := "invalid_password"
+		if user.Login == "" {
+			reason = "unknown_user"
+		}
+		return model.UserData{}, reason, fmt.Errorf("invalid password")
 	}
 
-	return user, nil
+	return user, "", nil
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	auditEntityOutboxMessage = "outbox_message"
+	auditOutboxReplayed      = "outbox.replayed"
+)
+
+// GetOutbox lists the undelivered side effects, status=pending|dead filters them.
+func GetOutbox(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	status := c.Query("status")
+	if status != "" && status != model.OutboxPending && status != model.OutboxDead {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for outbox filter",
+			"data":    "status has to be pending or dead"})
+	}
+
+	outbox, readerr := database.ReadOutbox()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading outbox",
+			"data":    readerr})
+	}
+
+	if status != "" {
+		filtered := []model.OutboxMessage{}
+		for _, message := range outbox.Messages {
+			if message.Status == status {
+				filtered = append(filtered, message)
+			}
+		}
+		outbox.Messages = filtered
+	}
+
+	outboxJson, err := json.MarshalIndent(outbox, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending outbox to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(outboxJson))
+}
+
+// ReplayOutboxMessage schedules a single message for immediate delivery with a fresh retry budget.
+func ReplayOutboxMessage(c *fiber.Ctx) error {
+	return replayOutbox(c, c.Params("messageId"))
+}
+
+// ReplayDeadOutboxMessages schedules every dead message for delivery again.
+func ReplayDeadOutboxMessages(c *fiber.Ctx) error {
+	return replayOutbox(c)
+}
+
+func replayOutbox(c *fiber.Ctx, ids ...string) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	before, readerr := database.ReadOutbox()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading outbox",
+			"data":    readerr})
+	}
+
+	replayed, replayerr := database.ReplayOutboxMessages(ids...)
+	if errors.Is(replayerr, database.ErrOutboxMessageNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "outbox message not found",
+			"data":    replayerr.Error()})
+	} else if replayerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while replaying outbox messages",
+			"data":    replayerr})
+	}
+
+	for _, message := range replayed {
+		for _, previous := range before.Messages {
+			if previous.Id == message.Id {
+				recordAudit(c, auditOutboxReplayed, auditEntityOutboxMessage, message.Id, message.ConferenceId, &previous, &message)
+				break
+			}
+		}
+	}
+
+	replayedJson, err := json.MarshalIndent(replayed, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending outbox to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(replayedJson))
+}
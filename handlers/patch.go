@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"booking-webapp/validation"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+var errUnsupportedPatchType = errors.New("unsupported patch format, use application/merge-patch+json (RFC 7386)")
+
+// updateMode tells an update handler which fields of the request body it applies.
+type updateMode struct {
+	fields   []string // patchable fields, empty for a full replacement (PUT)
+	required bool     // single-field routes, every listed field has to be present, see readFieldUpdate
+}
+
+var replaceUpdate = updateMode{}
+
+func mergePatchUpdate(fields ...string) updateMode {
+	return updateMode{fields: fields}
+}
+
+func fieldUpdate(field string) updateMode {
+	return updateMode{fields: []string{field}, required: true}
+}
+
+func (mode updateMode) isPartial() bool {
+	return len(mode.fields) > 0
+}
+
+// readUpdate reads the fields of a partial update, see readMergePatch and readFieldUpdate.
+func readUpdate(c *fiber.Ctx, dst interface{}, mode updateMode) (validation.Errors, error) {
+	if mode.required {
+		return readFieldUpdate(c, dst, mode)
+	}
+	return readMergePatch(c, dst, mode)
+}
+
+// readMergePatch decodes a JSON Merge Patch (RFC 7386) request body into dst.
+// Only the fields listed in mode are taken from the patch, any other member is reported as a violation.
+// A null member removes an optional field (validation rule omitempty) by setting it to its empty value,
+// the other fields cannot be removed.
+func readMergePatch(c *fiber.Ctx, dst interface{}, mode updateMode) (validation.Errors, error) {
+	mediaType, _, _ := strings.Cut(strings.ToLower(c.Get(fiber.HeaderContentType)), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if mediaType != fiber.MIMEApplicationJSON && mediaType != mergePatchContentType {
+		return nil, errUnsupportedPatchType
+	}
+
+	patch := map[string]json.RawMessage{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return nil, fmt.Errorf("patch document must be a JSON object: %v", err)
+	}
+
+	errs := validation.Errors{}
+	selected := map[string]json.RawMessage{}
+	removed := []string{}
+	for _, field := range mode.fields {
+		raw, ok := patch[field]
+		if !ok {
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			if validation.Removable(dst, field) {
+				removed = append(removed, field)
+			} else {
+				errs.Add(field, "cannot be removed")
+			}
+			continue
+		}
+		selected[field] = raw
+	}
+
+	unknownFields := []string{}
+	for key := range patch {
+		if !containsString(mode.fields, key) {
+			unknownFields = append(unknownFields, key)
+		}
+	}
+	sort.Strings(unknownFields)
+	for _, key := range unknownFields {
+		errs.Add(key, "cannot be patched")
+	}
+
+	selectedJson, err := json.Marshal(selected)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(selectedJson, dst); err != nil {
+		return nil, err
+	}
+	eachField(dst, func(name string, field reflect.Value) {
+		if containsString(removed, name) {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+	})
+
+	return errs, nil
+}
+
+// readFieldUpdate reads the body of the single-field routes with BodyParser, so they keep accepting
+// every body format they accepted before the merge patch routes. Only the fields of mode are kept,
+// they are required.
+func readFieldUpdate(c *fiber.Ctx, dst interface{}, mode updateMode) (validation.Errors, error) {
+	if err := c.BodyParser(dst); err != nil {
+		return nil, err
+	}
+
+	errs := validation.Errors{}
+	eachField(dst, func(name string, field reflect.Value) {
+		switch {
+		case !containsString(mode.fields, name):
+			field.Set(reflect.Zero(field.Type()))
+		case field.IsNil():
+			errs.Add(name, "is required")
+		}
+	})
+	return errs, nil
+}
+
+// eachField calls fn with the JSON name and the value of every pointer field of the struct dst points to.
+func eachField(dst interface{}, fn func(name string, field reflect.Value)) {
+	value := reflect.ValueOf(dst).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).Kind() != reflect.Ptr {
+			continue
+		}
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		fn(name, value.Field(i))
+	}
+}
+
+func patchError(c *fiber.Ctx, err error, message string) error {
+	status := fiber.StatusBadRequest
+	if err == errUnsupportedPatchType {
+		status = fiber.StatusUnsupportedMediaType
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    fmt.Sprint(err)})
+}
+
+func containsString(values []string, value string) bool {
+	for _, val := range values {
+		if val == value {
+			return true
+		}
+	}
+	return false
+}
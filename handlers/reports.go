@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/reporting"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetSalesSummary(c *fiber.Ctx) error {
+	return sendReport(c, func(period reporting.Period) (interface{}, error) {
+		conferences, readerr := database.ReadLocalDB()
+		if readerr != nil {
+			return nil, readerr
+		}
+		return reporting.Summarize(conferences, period), nil
+	})
+}
+
+func GetDailySales(c *fiber.Ctx) error {
+	return sendReport(c, func(period reporting.Period) (interface{}, error) {
+		conferences, readerr := database.ReadLocalDB()
+		if readerr != nil {
+			return nil, readerr
+		}
+		return reporting.Daily(conferences, period)
+	})
+}
+
+func GetConferenceSales(c *fiber.Ctx) error {
+	return sendReport(c, func(period reporting.Period) (interface{}, error) {
+		conference, geterr := database.GetConference(c.Params("confId"))
+		if geterr != nil {
+			return nil, geterr
+		}
+		return reporting.Conference(conference, period), nil
+	})
+}
+
+func GetConferenceDailySales(c *fiber.Ctx) error {
+	return sendReport(c, func(period reporting.Period) (interface{}, error) {
+		conference, geterr := database.GetConference(c.Params("confId"))
+		if geterr != nil {
+			return nil, geterr
+		}
+		return reporting.Daily([]model.Conference{conference}, period)
+	})
+}
+
+// sendReport checks the permissions and the from, to and tz parameters, and sends the computed report.
+func sendReport(c *fiber.Ctx, report func(period reporting.Period) (interface{}, error)) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	period, perioderr := reporting.ParsePeriod(c.Query("from"), c.Query("to"), c.Query("tz"))
+	if perioderr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for report period",
+			"data":    fmt.Sprint(perioderr)})
+	}
+
+	result, reporterr := report(period)
+	if errors.Is(reporterr, reporting.ErrSeriesTooLong) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for report period",
+			"data":    fmt.Sprint(reporterr)})
+	} else if reporterr != nil {
+		return database.HandleGetConferenceError(reporterr, c)
+	}
+
+	resultJson, err := json.MarshalIndent(result, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending report to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(resultJson))
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/live"
+	"booking-webapp/logging"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const streamRetryMillis = 3000
+
+// StreamConference pushes the remaining tickets and sales status of the conference as Server-Sent Events.
+// The current state is sent first unless the client resumes with a Last-Event-ID which is still current,
+// afterwards every change is pushed. Comment lines keep idle connections open.
+func StreamConference(c *fiber.Ctx) error {
+	heartbeat := config.Current.Stream.HeartbeatInterval
+
+	// subscribe before reading the state, so no change between both gets lost
+	subscription := live.Default.Subscribe(c.Params("id"))
+	conference, geterr := database.GetConference(c.Params("id"))
+	if geterr != nil {
+		subscription.Cancel()
+		return database.HandleGetConferenceError(geterr, c)
+	}
+	lastSeq, readerr := lastConferenceEvent(conference.Id)
+	if readerr != nil {
+		subscription.Cancel()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading the event log",
+			"data":    readerr})
+	}
+
+	current := live.NewUpdate(lastSeq, conference, false)
+	sendCurrent := true
+	if lastEventId, err := strconv.ParseUint(c.Get("Last-Event-ID"), 10, 64); err == nil && lastEventId >= lastSeq {
+		sendCurrent = false
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer subscription.Cancel()
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		fmt.Fprintf(w, "retry: %v\n\n", streamRetryMillis)
+		if sendCurrent {
+			writeStreamUpdate(w, current)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		sent := current.Id
+		for {
+			select {
+			case update, open := <-subscription.Updates:
+				if !open {
+					// the server shuts down, the client reconnects with Last-Event-ID
+					return
+				}
+				if update.Id <= sent {
+					continue
+				}
+				sent = update.Id
+				writeStreamUpdate(w, update)
+				if err := w.Flush(); err != nil || update.SalesStatus == live.Deleted {
+					return
+				}
+			case <-ticker.C:
+				w.WriteString(": heartbeat\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func writeStreamUpdate(w *bufio.Writer, update live.Update) {
+	eventName := "remaining_tickets"
+	if update.SalesStatus == live.Deleted {
+		eventName = "conference_deleted"
+	}
+	updateJson, err := json.Marshal(update)
+	if err != nil {
+		logging.Default.Error("cannot encode stream update", "conference_id", update.ConferenceId, "error", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", update.Id, eventName, updateJson)
+}
+
+// lastConferenceEvent returns the sequence number of the latest event of the conference.
+func lastConferenceEvent(confId string) (uint64, error) {
+	events, err := database.ReadEvents()
+	if err != nil {
+		return 0, err
+	}
+	for eventIndex := len(events) - 1; eventIndex >= 0; eventIndex-- {
+		if events[eventIndex].ConferenceId == confId {
+			return events[eventIndex].Seq, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+	"booking-webapp/reporting"
+	"booking-webapp/tickets"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	auditTicketCheckedIn = "ticket.checked_in"
+	qrCodeSize           = 256
+)
+
+// TicketInfo is a single ticket of a booking with its signed code.
+type TicketInfo struct {
+	Number      uint   `json:"number"`
+	Code        string `json:"code"`
+	CheckedInAt string `json:"checked_in_at,omitempty"`
+}
+
+// GetTickets lists the tickets of the booking with their codes, the same as the booking itself
+// they are available to everyone knowing the booking id.
+func GetTickets(c *fiber.Ctx) error {
+	booking, found, err := findActiveBooking(c)
+	if !found {
+		return err
+	}
+
+	ticketList := []TicketInfo{}
+	for number := uint(1); number <= booking.TicketsBooked; number++ {
+		code, codeerr := tickets.Issue(c.Params("confId"), booking.Id, number, ledger.TicketVersion(booking, number), booking.BookedAt)
+		if codeerr != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "server side problem occured while issuing tickets",
+				"data":    fmt.Sprint(codeerr)})
+		}
+		checkIn, _ := ledger.FindCheckIn(booking, number)
+		ticketList = append(ticketList, TicketInfo{Number: number, Code: code, CheckedInAt: checkIn.At})
+	}
+
+	ticketsJson, jsonerr := json.MarshalIndent(ticketList, "", "	")
+	if jsonerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending tickets to client",
+			"data":    jsonerr})
+	}
+
+	return c.SendString(string(ticketsJson))
+}
+
+// GetTicketQRCode renders the code of the ticket as QR code PNG.
+func GetTicketQRCode(c *fiber.Ctx) error {
+	booking, found, err := findActiveBooking(c)
+	if !found {
+		return err
+	}
+
+	number, converr := strconv.ParseUint(c.Params("ticket"), 10, 0)
+	if converr != nil || number < 1 || uint(number) > booking.TicketsBooked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "ticket not found",
+			"data":    fmt.Sprintf("booking %v has tickets 1 to %v", booking.Id, booking.TicketsBooked)})
+	}
+
+	code, codeerr := tickets.Issue(c.Params("confId"), booking.Id, uint(number), ledger.TicketVersion(booking, uint(number)), booking.BookedAt)
+	if codeerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while issuing tickets",
+			"data":    fmt.Sprint(codeerr)})
+	}
+	png, qrerr := qrcode.Encode(code, qrcode.Medium, qrCodeSize)
+	if qrerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while rendering QR code",
+			"data":    fmt.Sprint(qrerr)})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(png)
+}
+
+// CheckIn admits the ticket of the scanned code, every ticket can be checked in once.
+func CheckIn(c *fiber.Ctx) error {
+	if !hasRole(c, model.RoleAdmin, model.RoleStaff) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	req := struct {
+		Code string `json:"code"`
+	}{}
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for check-in",
+			"data":    "code of the scanned ticket is required"})
+	}
+
+	ticket, parseerr := tickets.Parse(req.Code, time.Now())
+	if errors.Is(parseerr, tickets.ErrOutsideValidity) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "ticket is not valid at this time",
+			"data": fmt.Sprintf("ticket is valid from %v until %v",
+				time.Unix(ticket.NotBefore, 0).Format(time.RFC3339), time.Unix(ticket.ExpiresAt, 0).Format(time.RFC3339))})
+	} else if errors.Is(parseerr, tickets.ErrInvalidCode) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "invalid ticket",
+			"data":    "ticket code is malformed or its signature does not match"})
+	} else if parseerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while verifying ticket",
+			"data":    fmt.Sprint(parseerr)})
+	}
+
+	conference, geterr := database.GetConference(ticket.ConferenceId)
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
+	}
+	booking := bookingById(conference, ticket.BookingId)
+	switch {
+	case booking.Id == "":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "booking not found",
+			"data":    fmt.Sprintf("no booking with id %v for conference id %v", ticket.BookingId, ticket.ConferenceId)})
+	case booking.IsCanceled:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "booking is canceled",
+			"data":    fmt.Sprintf("booking %v was canceled at %v", booking.Id, booking.UpdatedAt)})
+	case ticket.Number > booking.TicketsBooked:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "ticket is no longer valid",
+			"data":    fmt.Sprintf("booking %v was changed to %v tickets", booking.Id, booking.TicketsBooked)})
+	case ticket.Version != ledger.TicketVersion(booking, ticket.Number):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "ticket is no longer valid",
+			"data":    fmt.Sprintf("ticket %v of booking %v was revoked and issued again with a new code", ticket.Number, booking.Id)})
+	}
+	if checkIn, found := ledger.FindCheckIn(booking, ticket.Number); found {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "ticket is already checked in",
+			"data":    fmt.Sprintf("ticket %v of booking %v was checked in at %v", ticket.Number, booking.Id, checkIn.At)})
+	}
+
+	event := newEvent(c, model.EventTicketCheckedIn, conference.Id)
+	event.BookingId = booking.Id
+	event.TicketNumber = ticket.Number
+	updatedConf, commiterr := database.ApplyEvents(event)
+	if commiterr != nil {
+		return ledgerError(c, commiterr, "server side problem occured while saving check-in to the database")
+	}
+	checkedIn := bookingById(updatedConf, booking.Id)
+	recordAudit(c, auditTicketCheckedIn, auditEntityBooking, booking.Id, conference.Id, &booking, &checkedIn)
+
+	checkIn, _ := ledger.FindCheckIn(checkedIn, ticket.Number)
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "ticket checked in",
+		"data": fiber.Map{
+			"conference_id":   conference.Id,
+			"conference_name": conference.ConferenceName,
+			"booking_id":      booking.Id,
+			"customer_name":   booking.CustomerName,
+			"ticket":          ticket.Number,
+			"tickets_booked":  booking.TicketsBooked,
+			"checked_in_at":   checkIn.At,
+		}})
+}
+
+func GetCheckInStats(c *fiber.Ctx) error {
+	if !hasRole(c, model.RoleAdmin, model.RoleOrganizer, model.RoleStaff) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	conference, geterr := database.GetConference(c.Params("confId"))
+	if geterr != nil {
+		return database.HandleGetConferenceError(geterr, c)
+	}
+
+	statsJson, err := json.MarshalIndent(reporting.CheckIns(conference), "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending check-in stats to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(statsJson))
+}
+
+// GetTicketPublicKey returns the Ed25519 key which verifies ticket codes, e.g. for offline door scanners.
+func GetTicketPublicKey(c *fiber.Ctx) error {
+	publicKey, keyId, keyerr := tickets.PublicKey()
+	if keyerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading ticket key",
+			"data":    fmt.Sprint(keyerr)})
+	}
+
+	return c.JSON(fiber.Map{
+		"algorithm":  "Ed25519",
+		"key_id":     keyId,
+		"public_key": base64.StdEncoding.EncodeToString(publicKey),
+	})
+}
+
+// GetTicketRevocations lists the revocations after the since cursor, scanners sync it to reject
+// tickets of canceled or changed bookings offline.
+func GetTicketRevocations(c *fiber.Ctx) error {
+	if !hasRole(c, model.RoleAdmin, model.RoleStaff) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	since, converr := strconv.ParseUint(c.Query("since", "0"), 10, 64)
+	if converr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for revocation list cursor",
+			"data":    "since has to be the seq of the last synced entry"})
+	}
+
+	events, readerr := database.ReadEvents()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading the event log",
+			"data":    fmt.Sprint(readerr)})
+	}
+
+	cursor := since
+	if len(events) > 0 && events[len(events)-1].Seq > cursor {
+		cursor = events[len(events)-1].Seq
+	}
+	return c.JSON(fiber.Map{
+		"cursor":      cursor,
+		"revocations": tickets.Revocations(events, since),
+	})
+}
+
+// findActiveBooking looks up the booking of the route, found is false when the error response was sent.
+func findActiveBooking(c *fiber.Ctx) (model.Booking, bool, error) {
+	conference, geterr := database.GetConference(c.Params("confId"))
+	if geterr != nil {
+		return model.Booking{}, false, database.HandleGetConferenceError(geterr, c)
+	}
+
+	booking := bookingById(conference, c.Params("bookingId"))
+	if booking.Id == "" {
+		return booking, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "booking not found",
+			"data":    fmt.Errorf("no booking with id %v for conference id %v", c.Params("bookingId"), c.Params("confId"))})
+	}
+	if booking.IsCanceled {
+		return booking, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "booking is canceled, its tickets are not valid",
+			"data":    nil})
+	}
+	return booking, true, nil
+}
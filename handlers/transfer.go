@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/transfer"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var transferContentTypes = map[string]string{
+	transfer.FormatCSV:       "text/csv",
+	transfer.FormatJSONLines: "application/x-ndjson",
+}
+
+// ImportData imports conferences and bookings from CSV or JSON Lines rows, see transfer.Import.
+// With dry_run=true the rows are only validated.
+func ImportData(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	format := transferFormat(c)
+	if !transfer.IsFormat(format) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"status":  "error",
+			"message": "unsupported import format",
+			"data":    "use format=csv or format=jsonl, or Content-Type text/csv or application/x-ndjson"})
+	}
+
+	requestId, _ := c.Locals("requestid").(string)
+	report, importerr := transfer.Import(bytes.NewReader(c.Body()), format, transfer.Options{
+		DryRun:    c.Query("dry_run") == "true",
+		RequestId: requestId,
+	})
+	for _, imported := range report.Conferences {
+		if imported.After.Id == "" {
+			continue
+		}
+		if imported.Created {
+			recordAudit(c, auditConferenceCreated, auditEntityConference, imported.Id, imported.Id, nil, &imported.After)
+		}
+		for _, bookingId := range imported.Bookings {
+			booking := bookingById(imported.After, bookingId)
+			recordAudit(c, auditBookingCreated, auditEntityBooking, bookingId, imported.Id, nil, &booking)
+		}
+	}
+	var applyErr transfer.ApplyError
+	switch {
+	case errors.Is(importerr, database.ErrEventRejected):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "the conferences were changed meanwhile, nothing was imported, try again",
+			"data":    fmt.Sprint(importerr)})
+	case errors.As(importerr, &applyErr):
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while importing, nothing was imported",
+			"data":    fmt.Sprint(importerr)})
+	case importerr != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "import failed",
+			"data":    fmt.Sprint(importerr)})
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("%v errors found, nothing was imported", len(report.Errors)),
+			"data":    report})
+	case report.DryRun:
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": fmt.Sprintf("dry run, all %v rows are valid", report.Rows),
+			"data":    report})
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": fmt.Sprintf("%v rows imported", report.Rows),
+		"data":    report})
+}
+
+// ExportData streams all conferences with their bookings as CSV or JSON Lines rows.
+func ExportData(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	format := c.Query("format", transfer.FormatCSV)
+	if !transfer.IsFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "unsupported export format",
+			"data":    "use format=csv or format=jsonl"})
+	}
+
+	conferences, readerr := database.ReadLocalDB()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading conferences info from database",
+			"data":    readerr})
+	}
+
+	c.Set(fiber.HeaderContentType, transferContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="conferences.%v"`, format))
+	// the body is written after the handler returned, when the context belongs to the next request
+	logger := logging.For(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := transfer.Export(w, format, conferences); err != nil {
+			logger.Error("cannot export conferences", "error", err)
+		}
+	})
+	return nil
+}
+
+// transferFormat takes the format query parameter, or derives it from the Content-Type.
+func transferFormat(c *fiber.Ctx) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	contentType := strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0])
+	for format, formatType := range transferContentTypes {
+		if contentType == formatType {
+			return format
+		}
+	}
+	if contentType == "application/jsonl" {
+		return transfer.FormatJSONLines
+	}
+	return ""
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/validation"
+	"booking-webapp/webhooks"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	auditEntityWebhook = "webhook"
+
+	auditWebhookCreated = "webhook.created"
+	auditWebhookUpdated = "webhook.updated"
+	auditWebhookDeleted = "webhook.deleted"
+
+	defaultWebhookDeliveries = 50
+)
+
+func GetWebhooks(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	subscriptions, readerr := database.ReadWebhooks()
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading webhooks from database",
+			"data":    readerr})
+	}
+	for webhookIndex := range subscriptions {
+		subscriptions[webhookIndex].Secret = ""
+	}
+
+	return sendWebhookJson(c, subscriptions)
+}
+
+func GetWebhook(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	webhook, geterr := database.GetWebhook(c.Params("webhookId"))
+	if geterr != nil {
+		return webhookError(c, geterr)
+	}
+	webhook.Secret = ""
+
+	return sendWebhookJson(c, webhook)
+}
+
+// CreateWebhook subscribes a URL to the events, the response is the only place where a generated secret is shown.
+func CreateWebhook(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	req := new(model.WebhookRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for webhook parameters",
+			"data":    err})
+	}
+	if validationErrs := validation.Webhook(req, false); len(validationErrs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for webhook parameters",
+			"data":    validationErrs})
+	}
+
+	newUuid, _ := uuid.NewRandom()
+	now := time.Now().Format(time.RFC3339)
+	webhook := model.Webhook{
+		Id:        strings.Replace(newUuid.String(), "-", "", -1),
+		Url:       *req.Url,
+		Events:    *req.Events,
+		Secret:    webhooks.NewSecret(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+
+	if saveerr := database.SaveWebhook(webhook); saveerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while saving webhook to the database",
+			"data":    saveerr})
+	}
+	auditWebhook(c, auditWebhookCreated, webhook.Id, nil, &webhook)
+
+	return sendWebhookJson(c, webhook)
+}
+
+// UpdateWebhook replaces URL and events of the webhook, the secret is only changed when it is supplied.
+func UpdateWebhook(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	webhook, geterr := database.GetWebhook(c.Params("webhookId"))
+	if geterr != nil {
+		return webhookError(c, geterr)
+	}
+
+	req := new(model.WebhookRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for webhook parameters",
+			"data":    err})
+	}
+	if validationErrs := validation.Webhook(req, false); len(validationErrs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "incorrect input for webhook parameters",
+			"data":    validationErrs})
+	}
+
+	updated := webhook
+	updated.Url = *req.Url
+	updated.Events = *req.Events
+	updated.UpdatedAt = time.Now().Format(time.RFC3339)
+	if req.Secret != nil {
+		updated.Secret = *req.Secret
+	}
+
+	if saveerr := database.SaveWebhook(updated); saveerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while saving webhook to the database",
+			"data":    saveerr})
+	}
+	auditWebhook(c, auditWebhookUpdated, webhook.Id, &webhook, &updated)
+
+	if req.Secret == nil {
+		updated.Secret = ""
+	}
+	return sendWebhookJson(c, updated)
+}
+
+func DeleteWebhook(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	webhook, geterr := database.GetWebhook(c.Params("webhookId"))
+	if geterr != nil {
+		return webhookError(c, geterr)
+	}
+	if deleteerr := database.DeleteWebhook(webhook.Id); deleteerr != nil {
+		return webhookError(c, deleteerr)
+	}
+	auditWebhook(c, auditWebhookDeleted, webhook.Id, &webhook, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "webhook deleted",
+		"data":    fmt.Sprintf("webhook with id %v was deleted", webhook.Id)})
+}
+
+// GetWebhookDeliveries returns the latest delivery attempts of the webhook, newest first.
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	limit := defaultWebhookDeliveries
+	if c.Query("limit") != "" {
+		parsed, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || parsed < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "incorrect input for delivery log",
+				"data":    "limit has to be a positive number"})
+		}
+		limit = parsed
+	}
+
+	webhook, geterr := database.GetWebhook(c.Params("webhookId"))
+	if geterr != nil {
+		return webhookError(c, geterr)
+	}
+	deliveries, readerr := database.ReadWebhookDeliveries(webhook.Id, limit)
+	if readerr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while reading webhook deliveries",
+			"data":    readerr})
+	}
+
+	return sendWebhookJson(c, deliveries)
+}
+
+// PingWebhook sends a ping event right away and returns the delivery, which tells whether it was received.
+func PingWebhook(c *fiber.Ctx) error {
+	if !isAdminRole(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lack of permissions",
+			"data":    nil})
+	}
+
+	webhook, geterr := database.GetWebhook(c.Params("webhookId"))
+	if geterr != nil {
+		return webhookError(c, geterr)
+	}
+
+	eventUuid, _ := uuid.NewRandom()
+	event, err := webhooks.NewEvent("evt_ping_"+strings.Replace(eventUuid.String(), "-", "", -1), model.WebhookPing,
+		time.Now().Format(time.RFC3339), fiber.Map{"webhook_id": webhook.Id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while creating ping event",
+			"data":    err})
+	}
+
+	delivery, _ := webhooks.Send(webhook, event)
+	return sendWebhookJson(c, delivery)
+}
+
+// auditWebhook records the change without the secrets.
+func auditWebhook(c *fiber.Ctx, action string, webhookId string, before *model.Webhook, after *model.Webhook) {
+	var auditBefore, auditAfter interface{}
+	if before != nil {
+		withoutSecret := *before
+		withoutSecret.Secret = ""
+		auditBefore = &withoutSecret
+	}
+	if after != nil {
+		withoutSecret := *after
+		withoutSecret.Secret = ""
+		auditAfter = &withoutSecret
+	}
+	recordAudit(c, action, auditEntityWebhook, webhookId, "", auditBefore, auditAfter)
+}
+
+func webhookError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "webhook not found",
+			"data":    err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status":  "error",
+		"message": "server side problem occured while reading webhooks from database",
+		"data":    err})
+}
+
+func sendWebhookJson(c *fiber.Ctx, value interface{}) error {
+	valueJson, err := json.MarshalIndent(value, "", "	")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "server side problem occured while sending webhook info to client",
+			"data":    err})
+	}
+
+	return c.SendString(string(valueJson))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttendeesCSV(t *testing.T) {
+	app := setupTestApp(t, attendeesTestConferences())
+	token := tokenForRole(t, "organizer")
+
+	code, body := doRequest(t, app, "GET", "/v1/conference/conf1/attendees.csv", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "Customer name,Tickets,Booking ID,Booked at\n"+
+		"Adam Smith,1,booking3,2022-11-04T10:00:00+03:00\n"+
+		"Jane Doe,2,booking2,2022-11-03T10:00:00+03:00\n"+
+		"Roman Bauer,40,booking1,2022-11-02T16:29:20+03:00\n", body)
+
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/attendees.csv?per_ticket=true&columns=customer_name,ticket", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, "Jane Doe,1 of 2\nJane Doe,2 of 2\n")
+	assert.Contains(t, body, "Roman Bauer,40 of 40\n")
+
+	code, _ = doRequest(t, app, "GET", "/v1/conference/conf1/attendees.csv?columns=ticket", "", token, nil)
+	assert.Equal(t, 400, code)
+	code, _ = doRequest(t, app, "GET", "/v1/conference/conf1/attendees.csv", "", tokenForRole(t, "customer"), nil)
+	assert.Equal(t, 401, code)
+	code, _ = doRequest(t, app, "GET", "/v1/conference/unknown/attendees.csv", "", token, nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestAttendeesCSVEscapesFormulas(t *testing.T) {
+	conferences := attendeesTestConferences()
+	conferences[0].Bookings[1].CustomerName = "=HYPERLINK(\"http://example.com\")"
+	conferences[0].Bookings[2].CustomerName = "@SUM(A1)"
+	conferences[0].Bookings[0].CustomerName = "\t=SUM(A1)"
+	conferences[0].RemainingTickets -= 2
+	conferences[0].Bookings = append(conferences[0].Bookings, conferences[0].Bookings[1])
+	conferences[0].Bookings[4].Id = "booking5"
+	conferences[0].Bookings[4].CustomerName = "\r=SUM(A1)"
+	app := setupTestApp(t, conferences)
+
+	code, body := doRequest(t, app, "GET", "/v1/conference/conf1/attendees.csv?columns=customer_name,tickets_booked", "", tokenForRole(t, "organizer"), nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "Customer name,Tickets\n"+
+		"'\t=SUM(A1),40\n"+
+		"\"'\r=SUM(A1)\",2\n"+
+		"\"'=HYPERLINK(\"\"http://example.com\"\")\",2\n"+
+		"'@SUM(A1),1\n", body)
+}
+
+func TestAttendeesXLSX(t *testing.T) {
+	app := setupTestApp(t, attendeesTestConferences())
+
+	code, body := doRequest(t, app, "GET", "/v1/conference/conf1/attendees.xlsx", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	archive, err := zip.NewReader(bytes.NewReader([]byte(body)), int64(len(body)))
+	if assert.NoError(t, err) {
+		assert.Len(t, archive.File, 6)
+	}
+}
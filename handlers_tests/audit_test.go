@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTrail(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	adminToken := tokenForRole(t, "admin")
+
+	code, body := doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking1", "application/merge-patch+json", tokenForRole(t, "customer"), []byte(`{"tickets_booked": 2}`))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "PATCH", "/v1/conference/conf1/name", "application/json", adminToken, []byte(`{"conference_name": "Boston 2024"}`))
+	assert.Equalf(t, 200, code, body)
+
+	code, body = doRequest(t, app, "GET", "/v1/audit?entity=booking", "", tokenForRole(t, "customer"), nil)
+	assert.Equal(t, 401, code)
+
+	var entries []model.AuditEntry
+	code, body = doRequest(t, app, "GET", "/v1/audit?entity=booking", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &entries))
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "test_customer", entries[0].Actor)
+		assert.Equal(t, "booking.updated", entries[0].Action)
+		assert.NotEmpty(t, entries[0].RequestId)
+		assert.Equal(t, model.FieldChange{Before: float64(40), After: float64(2)}, entries[0].Changes["tickets_booked"])
+	}
+
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/history", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &entries))
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "conference.updated", entries[1].Action)
+		assert.Equal(t, model.FieldChange{Before: "Boston 2023", After: "Boston 2024"}, entries[1].Changes["conference_name"])
+		assert.NotContains(t, entries[1].Changes, "total_tickets")
+	}
+}
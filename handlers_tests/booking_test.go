@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBookingHidesCustomerData(t *testing.T) {
+	conferences := testConferences()
+	conferences[0].Bookings[0].CustomerEmail = "roman@example.com"
+	conferences[0].Bookings[0].CheckIns = []model.TicketCheckIn{{Ticket: 1, At: "2022-12-01T09:00:00+01:00"}}
+	app := setupTestApp(t, conferences)
+
+	for _, token := range []string{"", tokenForRole(t, "customer"), tokenForRole(t, "staff"), "invalid"} {
+		code, body := doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking1", "", token, nil)
+		assert.Equalf(t, 200, code, body)
+		assert.Contains(t, body, "Roman Bauer")
+		assert.NotContains(t, body, "roman@example.com")
+		assert.NotContains(t, body, "check_ins")
+	}
+
+	for _, role := range []string{"admin", "organizer"} {
+		code, body := doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking1", "", tokenForRole(t, role), nil)
+		assert.Equalf(t, 200, code, body)
+		assert.Contains(t, body, "roman@example.com")
+		assert.Contains(t, body, "check_ins")
+	}
+}
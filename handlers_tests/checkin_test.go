@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTicket struct {
+	Number uint   `json:"number"`
+	Code   string `json:"code"`
+}
+
+func checkInRequest(code string) []byte {
+	body, _ := json.Marshal(map[string]string{"code": code})
+	return body
+}
+
+func TestCheckIn(t *testing.T) {
+	// the fixture bookings are from 2022
+	prevValidity := config.Current.Tickets.Validity
+	config.Current.Tickets.Validity = 87600 * time.Hour
+	t.Cleanup(func() { config.Current.Tickets.Validity = prevValidity })
+	app := setupTestApp(t, attendeesTestConferences())
+	staffToken := tokenForRole(t, "staff")
+
+	var tickets []testTicket
+	code, body := doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking2/tickets", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &tickets))
+	if !assert.Len(t, tickets, 2) {
+		return
+	}
+
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking2/tickets/2/qr.png", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "\x89PNG", body[:4])
+	code, _ = doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking2/tickets/3/qr.png", "", "", nil)
+	assert.Equal(t, 404, code)
+
+	code, _ = doRequest(t, app, "POST", "/v1/checkin", "application/json", tokenForRole(t, "customer"), checkInRequest(tickets[0].Code))
+	assert.Equal(t, 401, code)
+	code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[0].Code))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[0].Code))
+	assert.Equal(t, 409, code)
+	assert.Contains(t, body, "already checked in")
+	code, _ = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[1].Code[:len(tickets[1].Code)-2]+"AA"))
+	assert.Equal(t, 400, code)
+
+	code, body = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking2/tickets", "application/json", "", []byte(`{"tickets_booked": 1}`))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[1].Code))
+	assert.Equal(t, 409, code)
+	assert.Contains(t, body, "no longer valid")
+
+	code, body = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking2/tickets", "application/json", "", []byte(`{"tickets_booked": 2}`))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[1].Code))
+	assert.Equal(t, 409, code, "the revoked code stays invalid when the ticket is booked again")
+	assert.Contains(t, body, "issued again")
+	var reissued []testTicket
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/booking/booking2/tickets", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &reissued))
+	if assert.Len(t, reissued, 2) {
+		assert.Equal(t, tickets[0].Code, reissued[0].Code)
+		assert.NotEqual(t, tickets[1].Code, reissued[1].Code)
+		code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(reissued[1].Code))
+		assert.Equalf(t, 200, code, body)
+	}
+	code, body = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking2/cancel", "", "", nil)
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "POST", "/v1/checkin", "application/json", staffToken, checkInRequest(tickets[1].Code))
+	assert.Equal(t, 409, code)
+	assert.Contains(t, body, "canceled")
+
+	code, body = doRequest(t, app, "GET", "/v1/tickets/public-key", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"algorithm":"Ed25519"`)
+
+	var revocationList struct {
+		Cursor      uint64 `json:"cursor"`
+		Revocations []struct {
+			Reason    string `json:"reason"`
+			BookingId string `json:"booking_id"`
+		} `json:"revocations"`
+	}
+	code, body = doRequest(t, app, "GET", "/v1/tickets/revocations?since=0", "", staffToken, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &revocationList))
+	if assert.Len(t, revocationList.Revocations, 4) {
+		assert.Equal(t, "booking_canceled", revocationList.Revocations[3].Reason)
+		assert.Equal(t, "booking2", revocationList.Revocations[3].BookingId)
+	}
+	code, body = doRequest(t, app, "GET", fmt.Sprintf("/v1/tickets/revocations?since=%v", revocationList.Cursor), "", staffToken, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"revocations":[]`)
+	code, _ = doRequest(t, app, "GET", "/v1/tickets/revocations", "", tokenForRole(t, "customer"), nil)
+	assert.Equal(t, 401, code)
+
+	var stats map[string]interface{}
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/checkin/stats", "", tokenForRole(t, "organizer"), nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &stats))
+	assert.Equal(t, float64(41), stats["tickets"], fmt.Sprint(stats))
+	assert.Equal(t, float64(0), stats["checked_in"], "the checked-in booking was canceled")
+}
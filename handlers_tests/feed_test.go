@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/live"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+var publishFeed sync.Once
+
+func readFeedMessage(t *testing.T, conn *websocket.Conn) live.FeedMessage {
+	var message live.FeedMessage
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestActivityFeed(t *testing.T) {
+	publishFeed.Do(func() { database.OnCommit(live.Activity.PublishCommit) })
+	app := setupTestApp(t, attendeesTestConferences())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+	feedUrl := "ws://" + listener.Addr().String() + "/v1/admin/feed"
+
+	_, res, err := websocket.DefaultDialer.Dial(feedUrl+"?access_token="+tokenForRole(t, "user"), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 401, res.StatusCode)
+	_, res, err = websocket.DefaultDialer.Dial(feedUrl, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	code, _ := doRequest(t, app, "GET", "/v1/admin/feed", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 426, code)
+
+	conn, _, err := websocket.DefaultDialer.Dial(feedUrl+"?conference_id=conf2&access_token="+tokenForRole(t, "organizer"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	subscribed := readFeedMessage(t, conn)
+	assert.Equal(t, live.FeedSubscribed, subscribed.Type)
+	assert.Equal(t, []string{"conf2"}, subscribed.ConferenceIds)
+
+	// changes of other conferences are filtered out
+	code, _ = doRequest(t, app, "PATCH", "/v1/conference/conf1/name", "application/json", tokenForRole(t, "admin"), []byte(`{"conference_name": "Renamed"}`))
+	assert.Equal(t, 200, code)
+
+	assert.NoError(t, conn.WriteJSON(map[string]interface{}{"conference_ids": []string{"conf1"}}))
+	assert.Equal(t, []string{"conf1"}, readFeedMessage(t, conn).ConferenceIds)
+	code, _ = doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Ada Lovelace", "tickets_booked": 2}`))
+	assert.Equal(t, 200, code)
+	change := readFeedMessage(t, conn)
+	assert.Equal(t, live.FeedChange, change.Type)
+	assert.Equal(t, "conf1", change.ConferenceId)
+	assert.Equal(t, "BookingCreated", change.Events[0].Type)
+	assert.Equal(t, uint(2), change.Events[0].TicketsBooked)
+	assert.Equal(t, change.Events[0].Seq, change.Seq)
+	assert.Equal(t, uint(105), change.State.RemainingTickets)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+)
+
+// testConferences is the store most tests start with, a conference with a single booking.
+func testConferences() []model.Conference {
+	return []model.Conference{{
+		Id:               "conf1",
+		ConferenceName:   "Boston 2023",
+		TotalTickets:     150,
+		RemainingTickets: 110,
+		Bookings: []model.Booking{{
+			Id:            "booking1",
+			CustomerName:  "Roman Bauer",
+			TicketsBooked: 40,
+			BookedAt:      "2022-11-02T16:29:20+03:00",
+			UpdatedAt:     "2022-11-02T16:29:20+03:00",
+		}},
+	}}
+}
+
+// attendeesTestConferences adds more bookings to testConferences, one of them canceled.
+func attendeesTestConferences() []model.Conference {
+	conferences := testConferences()
+	conferences[0].RemainingTickets = 107
+	conferences[0].Bookings = append(conferences[0].Bookings,
+		model.Booking{Id: "booking2", CustomerName: "Jane Doe", TicketsBooked: 2, BookedAt: "2022-11-03T10:00:00+03:00", UpdatedAt: "2022-11-03T10:00:00+03:00"},
+		model.Booking{Id: "booking3", CustomerName: "Adam Smith", TicketsBooked: 1, BookedAt: "2022-11-04T10:00:00+03:00", UpdatedAt: "2022-11-04T10:00:00+03:00"},
+		model.Booking{Id: "booking4", CustomerName: "Bob Canceled", TicketsBooked: 5, BookedAt: "2022-11-04T11:00:00+03:00", UpdatedAt: "2022-11-04T12:00:00+03:00", IsCanceled: true},
+	)
+	return conferences
+}
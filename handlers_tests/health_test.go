@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/health"
+	"booking-webapp/model"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbes(t *testing.T) {
+	app := setupTestApp(t, []model.Conference{})
+	prevChecker := health.Default
+	health.Default = health.NewChecker()
+	t.Cleanup(func() { health.Default = prevChecker })
+	health.Default.Register("storage", database.CheckStorage)
+
+	status, body := doRequest(t, app, "GET", "/healthz", "", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"phase":"starting"`)
+
+	// not ready until the server listens
+	status, body = doRequest(t, app, "GET", "/readyz", "", "", nil)
+	assert.Equal(t, 503, status)
+	assert.Contains(t, body, `"phase":"starting"`)
+
+	health.Default.SetPhase(health.Serving)
+	status, body = doRequest(t, app, "GET", "/readyz", "", "", nil)
+	assert.Equal(t, 200, status, body)
+	assert.Contains(t, body, `"storage":{"status":"up"`)
+
+	assert.NoError(t, os.Remove(config.EVENT_LOG_PATH))
+	status, body = doRequest(t, app, "GET", "/readyz", "", "", nil)
+	assert.Equal(t, 503, status)
+	assert.Contains(t, body, `"storage":{"status":"down","error":"cannot read `)
+
+	health.Default.SetPhase(health.Draining)
+	status, body = doRequest(t, app, "GET", "/healthz", "", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"phase":"draining"`)
+}
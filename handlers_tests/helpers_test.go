@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/router"
+	"bytes"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt"
+)
+
+const testSign = "test-sign"
+
+// setupTestApp points the local files of the service to a temp dir, fills it with conferences and returns a ready app.
+func setupTestApp(t *testing.T, conferences []model.Conference) *fiber.App {
+	t.Setenv("SIGN", testSign)
+
+	dbDir := t.TempDir()
+	for _, path := range config.StoragePaths() {
+		useTestPath(t, path, filepath.Join(dbDir, filepath.Base(*path)))
+	}
+
+	if err := database.CommitConferencesToLocalDB(conferences); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.InitLedger(); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	router.SetupRoutes(app)
+	return app
+}
+
+func useTestPath(t *testing.T, path *string, testPath string) {
+	prevPath := *path
+	*path = testPath
+	t.Cleanup(func() { *path = prevPath })
+}
+
+func tokenForRole(t *testing.T, role string) string {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["username"] = "test_" + role
+	claims["role"] = role
+
+	signed, err := token.SignedString([]byte(testSign))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// doRequest sends the request and returns the status code with the response body.
+func doRequest(t *testing.T, app *fiber.App, method string, route string, contentType string, token string, body []byte) (int, string) {
+	req, _ := http.NewRequest(method, route, bytes.NewBuffer(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(resBody)
+}
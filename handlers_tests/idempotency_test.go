@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentBookingCreation(t *testing.T) {
+	app := setupTestApp(t, []model.Conference{{
+		Id:               "conf1",
+		ConferenceName:   "Boston 2023",
+		TotalTickets:     150,
+		RemainingTickets: 150,
+		Bookings:         []model.Booking{},
+	}})
+
+	bookAt := func(route string, token string, key string, body string) (int, string, string) {
+		req, _ := http.NewRequest("POST", route, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resBody, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(resBody), res.Header.Get("Idempotent-Replayed")
+	}
+	book := func(key string, body string) (int, string, string) {
+		return bookAt("/v1/conference/conf1/booking", "", key, body)
+	}
+
+	code, firstBody, replayed := book("key-1", `{"customer_name": "Roman Bauer", "tickets_booked": 2}`)
+	assert.Equal(t, 200, code)
+	assert.Empty(t, replayed)
+
+	code, retryBody, replayed := book("key-1", `{"customer_name": "Roman Bauer", "tickets_booked": 2}`)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, firstBody, retryBody)
+	assert.Equal(t, "true", replayed)
+
+	code, _, _ = book("key-1", `{"customer_name": "Roman Bauer", "tickets_booked": 3}`)
+	assert.Equal(t, 422, code)
+
+	// the deprecated alias is the same resource
+	code, aliasBody, replayed := bookAt("/conference/conf1/booking/", "", "key-1", `{"customer_name": "Roman Bauer", "tickets_booked": 2}`)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, firstBody, aliasBody)
+	assert.Equal(t, "true", replayed)
+
+	code, _, _ = book("key-2", `{"customer_name": "Roman Bauer", "tickets_booked": 2}`)
+	assert.Equal(t, 200, code)
+
+	// keys are scoped by the caller
+	code, _, replayed = bookAt("/v1/conference/conf1/booking", tokenForRole(t, "organizer"), "key-1", `{"customer_name": "Roman Bauer", "tickets_booked": 3}`)
+	assert.Equal(t, 200, code)
+	assert.Empty(t, replayed)
+
+	// a request which never completed, e.g. because of a crash, blocks its key only for a short time
+	_, err := database.ReserveIdempotencyKey(model.IdempotencyRecord{
+		Scope:     "anonymous",
+		Key:       "key-3",
+		ExpiresAt: time.Now().Add(-time.Second).Format(time.RFC3339),
+	})
+	assert.NoError(t, err)
+	code, _, _ = book("key-3", `{"customer_name": "Roman Bauer", "tickets_booked": 1}`)
+	assert.Equal(t, 200, code)
+
+	conference, _ := database.GetConference("conf1")
+	assert.Len(t, conference.Bookings, 4)
+	assert.Equal(t, uint(142), conference.RemainingTickets)
+}
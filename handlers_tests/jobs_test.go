@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+	"booking-webapp/scheduler"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	jobScheduler, err := scheduler.NewScheduler(scheduler.LocalElector{}, scheduler.BuiltinJobs()...)
+	assert.NoError(t, err)
+	assert.NoError(t, jobScheduler.Poll())
+
+	code, _ := doRequest(t, app, "GET", "/v1/admin/jobs", "", tokenForRole(t, "organizer"), nil)
+	assert.Equal(t, 401, code)
+
+	code, body := doRequest(t, app, "GET", "/v1/admin/jobs", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	jobs := []model.JobRecord{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &jobs))
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, scheduler.RemindersJob, jobs[0].Name)
+		assert.Equal(t, "*/15 * * * *", jobs[0].Schedule)
+		assert.Equal(t, model.JobScheduled, jobs[0].Status)
+		assert.NotEmpty(t, jobs[0].NextRunAt)
+	}
+
+	code, body = doRequest(t, app, "POST", "/v1/admin/jobs/cleanup/run", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 202, code)
+	assert.Contains(t, body, "job scheduled")
+	assert.NoError(t, jobScheduler.Poll())
+	code, body = doRequest(t, app, "GET", "/v1/admin/jobs/cleanup", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	cleanup := model.JobRecord{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &cleanup))
+	assert.Equal(t, 1, cleanup.Runs)
+	assert.NotEmpty(t, cleanup.LastSuccessAt)
+
+	code, _ = doRequest(t, app, "POST", "/v1/admin/jobs/unknown/run", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 404, code)
+	code, _ = doRequest(t, app, "GET", "/v1/admin/jobs/unknown", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestConferenceStartsAt(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+
+	code, body := doRequest(t, app, "PATCH", "/v1/conference/conf1", "application/merge-patch+json", tokenForRole(t, "admin"), []byte(`{"starts_at": "next week"}`))
+	assert.Equal(t, 400, code)
+	assert.Contains(t, body, "starts_at")
+
+	code, body = doRequest(t, app, "PATCH", "/v1/conference/conf1", "application/merge-patch+json", tokenForRole(t, "admin"), []byte(`{"starts_at": "2023-05-10T09:00:00+02:00"}`))
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"starts_at": "2023-05-10T09:00:00+02:00"`)
+
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf1/history", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"after": "2023-05-10T09:00:00+02:00"`)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"booking-webapp/logging"
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lockedBuffer collects the log, workers of other tests may log at the same time.
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func (b *lockedBuffer) lines(requestId string) []string {
+	var matching []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.Contains(line, `"request_id":"`+requestId+`"`) {
+			matching = append(matching, line)
+		}
+	}
+	return matching
+}
+
+func TestRequestLogs(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	out := &lockedBuffer{}
+	logging.Default.SetOutput(out)
+	t.Cleanup(func() { logging.Default.SetOutput(os.Stderr) })
+
+	// the id of the client is echoed and ties the request log to the storage log
+	req, _ := http.NewRequest("POST", "/v1/conference/conf1/booking?access_token=secret-token", bytes.NewBufferString(`{"customer_name": "Ada Lovelace", "tickets_booked": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-42")
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "req-42", res.Header.Get("X-Request-ID"))
+
+	lines := out.lines("req-42")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"msg":"events committed"`)
+		assert.Contains(t, lines[0], `"types":["BookingCreated"]`)
+		assert.Contains(t, lines[1], `"level":"info","msg":"request"`)
+		assert.Contains(t, lines[1], `"path":"/v1/conference/conf1/booking","status":200`)
+		assert.Contains(t, lines[1], `"query":"access_token=[REDACTED]"`)
+	}
+
+	// invalid ids are replaced, credentials never reach the log
+	req, _ = http.NewRequest("POST", "/v1/login", bytes.NewBufferString(`{"login": "nobody", "password": "hunter2"}`))
+	req.Header.Set("X-Request-ID", "bad id\n")
+	res, err = app.Test(req, -1)
+	assert.NoError(t, err)
+	requestId := res.Header.Get("X-Request-ID")
+	assert.Len(t, requestId, 36)
+	assert.Len(t, out.lines(requestId), 1)
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "secret-token")
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/metrics"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var recordMetrics sync.Once
+
+func TestMetrics(t *testing.T) {
+	recordMetrics.Do(func() { database.OnCommit(metrics.RecordCommit) })
+	app := setupTestApp(t, testConferences())
+
+	code, _ := doRequest(t, app, "GET", "/v1/conference/conf1", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "GET", "/v1/no-such-route", "", "", nil)
+	assert.Equal(t, 404, code)
+	code, _ = doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Ada Lovelace", "tickets_booked": 5}`))
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "POST", "/v1/login", "", "", nil)
+	assert.Equal(t, 200, code)
+
+	code, body := doRequest(t, app, "GET", "/metrics", "", "", nil)
+	assert.Equal(t, 200, code)
+	// routes are labeled with their pattern
+	assert.Contains(t, body, `booking_http_requests_total{method="GET",route="/v1/conference/:id",status="200"}`)
+	assert.Contains(t, body, `booking_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.Contains(t, body, `booking_http_request_duration_seconds_bucket{method="POST",route="/v1/conference/:confId/booking",status="200",le="0.005"}`)
+	assert.Contains(t, body, `booking_storage_operation_duration_seconds_count{operation="append_events"}`)
+	assert.Contains(t, body, `booking_conference_tickets_remaining{conference_id="conf1"} 105`)
+	assert.Contains(t, body, `booking_conference_tickets_sold{conference_id="conf1"} 45`)
+	assert.Contains(t, body, `booking_bookings_created_total`)
+	assert.Contains(t, body, `booking_logins_total{reason="anonymous",result="success"}`)
+	assert.NotContains(t, body, `route="/metrics"`)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"booking-webapp/notify"
+	"booking-webapp/outbox"
+	"booking-webapp/scheduler"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSender struct {
+	mutex    sync.Mutex
+	messages []notify.Message
+}
+
+func (s *recordingSender) Send(message notify.Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func TestBookingNotifications(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	sender := &recordingSender{}
+	notify.SetSender(sender)
+	t.Cleanup(func() { notify.SetSender(nil) })
+	dispatcher := outbox.NewDispatcher(scheduler.LocalElector{}, outbox.Notifications{})
+	assert.NoError(t, dispatcher.Poll())
+
+	code, body := doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Jane Doe", "tickets_booked": 2, "customer_email": "not an email"}`))
+	assert.Equal(t, 400, code)
+	assert.Contains(t, body, "customer_email")
+
+	var booking struct {
+		Id            string `json:"id"`
+		CustomerEmail string `json:"customer_email"`
+	}
+	code, body = doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Jane Doe", "tickets_booked": 2, "customer_email": "jane@example.com"}`))
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &booking))
+	assert.Equal(t, "jane@example.com", booking.CustomerEmail)
+
+	route := "/v1/conference/conf1/booking/" + booking.Id
+	code, _ = doRequest(t, app, "PATCH", route, "application/merge-patch+json", "", []byte(`{"tickets_booked": 3}`))
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "PATCH", route+"/cancel", "", "", nil)
+	assert.Equal(t, 200, code)
+	// booking1 has no email address
+	code, _ = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking1/cancel", "", "", nil)
+	assert.Equal(t, 200, code)
+
+	assert.NoError(t, dispatcher.Poll())
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	subjects := []string{}
+	for _, message := range sender.messages {
+		assert.Equal(t, "jane@example.com", message.To)
+		subjects = append(subjects, message.Subject)
+	}
+	assert.ElementsMatch(t, []string{
+		"Your booking for Boston 2023 is confirmed",
+		"Your booking for Boston 2023 was changed",
+		"Your booking for Boston 2023 was canceled",
+	}, subjects)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"booking-webapp/docs"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// specPath converts a fiber route path into the OpenAPI path template.
+// Versioned routes are documented relative to their server, e.g. /v1.
+func specPath(routePath string) string {
+	path := routeParam.ReplaceAllString(strings.TrimPrefix(routePath, "/v1"), "{$1}")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(docs.OpenAPI, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	app := setupTestApp(t, nil)
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		path := specPath(route.Path)
+		_, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		assert.Truef(t, ok, "route %v %v is missing in docs/openapi.json", route.Method, path)
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	app := setupTestApp(t, nil)
+
+	code, body := doRequest(t, app, "GET", "/openapi.json", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, string(docs.OpenAPI), body)
+
+	code, body = doRequest(t, app, "GET", "/docs", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, "swagger-ui")
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+	"booking-webapp/notify"
+	"booking-webapp/outbox"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSender struct{}
+
+func (failingSender) Send(message notify.Message) error {
+	return errors.New("connection refused")
+}
+
+func TestOutboxReplay(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	adminToken := tokenForRole(t, "admin")
+	notify.SetSender(failingSender{})
+	t.Cleanup(func() { notify.SetSender(nil) })
+	dispatcher := &outbox.Dispatcher{Subscribers: []outbox.Subscriber{outbox.Notifications{}}, MaxAttempts: 1, RetryBase: time.Second, MaxBackoff: time.Second, Now: time.Now}
+	assert.NoError(t, dispatcher.Poll())
+
+	code, _ := doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Jane Doe", "tickets_booked": 2, "customer_email": "jane@example.com"}`))
+	assert.Equal(t, 200, code)
+	assert.NoError(t, dispatcher.Poll())
+
+	var box model.Outbox
+	code, body := doRequest(t, app, "GET", "/v1/admin/outbox?status=dead", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &box))
+	if !assert.Len(t, box.Messages, 1) {
+		return
+	}
+	assert.Equal(t, "connection refused", box.Messages[0].LastError)
+	messageId := box.Messages[0].Id
+
+	code, _ = doRequest(t, app, "GET", "/v1/admin/outbox", "", tokenForRole(t, "user"), nil)
+	assert.Equal(t, 401, code)
+	code, _ = doRequest(t, app, "GET", "/v1/admin/outbox?status=sent", "", adminToken, nil)
+	assert.Equal(t, 400, code)
+	code, _ = doRequest(t, app, "POST", "/v1/admin/outbox/unknown/replay", "", adminToken, nil)
+	assert.Equal(t, 404, code)
+
+	code, body = doRequest(t, app, "POST", "/v1/admin/outbox/"+messageId+"/replay", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"status": "pending"`)
+
+	sender := &recordingSender{}
+	notify.SetSender(sender)
+	assert.NoError(t, dispatcher.Poll())
+	if assert.Len(t, sender.messages, 1) {
+		assert.Equal(t, messageId, sender.messages[0].Id)
+	}
+	code, body = doRequest(t, app, "GET", "/v1/admin/outbox", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"messages": []`)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchConference(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	token := tokenForRole(t, "admin")
+
+	tests := []struct {
+		description  string
+		route        string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"merge patch of one field", "/conference/conf1", "application/merge-patch+json", `{"total_tickets": 200}`, 200},
+		{"read-only field", "/conference/conf1", "application/merge-patch+json", `{"remaining_tickets": 1}`, 400},
+		{"removing a field", "/conference/conf1", "application/merge-patch+json", `{"conference_name": null}`, 400},
+		{"below booked tickets", "/conference/conf1", "application/merge-patch+json", `{"total_tickets": 10}`, 400},
+		{"json patch is not supported", "/conference/conf1", "application/json-patch+json", `[]`, 415},
+		{"unknown conference", "/conference/unknown", "application/merge-patch+json", `{"total_tickets": 200}`, 404},
+		{"name alias with query string", "/conference/conf1/name?source=app", "application/json", `{"conference_name": "Boston 2024", "total_tickets": 1}`, 200},
+		{"name alias without name", "/conference/conf1/name", "application/json", `{"total_tickets": 300}`, 400},
+	}
+
+	for _, test := range tests {
+		code, body := doRequest(t, app, "PATCH", test.route, test.contentType, token, []byte(test.body))
+		assert.Equalf(t, test.expectedCode, code, "%v: %v", test.description, body)
+	}
+
+	conference, err := database.GetConference("conf1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Boston 2024", conference.ConferenceName)
+	assert.Equal(t, uint(200), conference.TotalTickets)
+	assert.Equal(t, uint(160), conference.RemainingTickets)
+}
+
+func TestPatchBooking(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+
+	code, body := doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1", "application/merge-patch+json", "", []byte(`{"tickets_booked": 100}`))
+	assert.Equalf(t, 200, code, body)
+
+	code, body = doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1/tickets/", "application/json", "", []byte(`{"tickets_booked": 90}`))
+	assert.Equalf(t, 200, code, body)
+
+	code, body = doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1", "application/merge-patch+json", "", []byte(`{"customer_name": "Roman", "is_canceled": true}`))
+	assert.Equalf(t, 400, code, body)
+
+	// the handler stops after the not found response
+	code, body = doRequest(t, app, "PATCH", "/conference/unknown/booking/booking1", "application/merge-patch+json", "", []byte(`{"tickets_booked": 1}`))
+	assert.Equalf(t, 404, code, body)
+	assert.Contains(t, body, "Error on getting info from database")
+
+	conference, _ := database.GetConference("conf1")
+	assert.Equal(t, "Roman Bauer", conference.Bookings[0].CustomerName)
+	assert.Equal(t, uint(90), conference.Bookings[0].TicketsBooked)
+	assert.Equal(t, uint(60), conference.RemainingTickets)
+}
+
+func TestPatchRemovesOptionalFields(t *testing.T) {
+	conferences := testConferences()
+	conferences[0].StartsAt = "2023-05-02T09:00:00+02:00"
+	conferences[0].Bookings[0].CustomerEmail = "roman@example.com"
+	app := setupTestApp(t, conferences)
+
+	code, body := doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1", "application/merge-patch+json", "", []byte(`{"customer_email": null}`))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1", "application/merge-patch+json", "", []byte(`{"customer_name": null}`))
+	assert.Equalf(t, 400, code, body)
+	assert.Contains(t, body, "cannot be removed")
+	code, body = doRequest(t, app, "PATCH", "/conference/conf1", "application/merge-patch+json", tokenForRole(t, "admin"), []byte(`{"starts_at": null}`))
+	assert.Equalf(t, 200, code, body)
+
+	conference, _ := database.GetConference("conf1")
+	assert.Empty(t, conference.StartsAt)
+	assert.Empty(t, conference.Bookings[0].CustomerEmail)
+	assert.Equal(t, "Roman Bauer", conference.Bookings[0].CustomerName)
+}
+
+func TestFieldAliasesAcceptFormBodies(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+
+	// form fields have the names of the struct fields, as before the merge patch routes
+	code, body := doRequest(t, app, "PATCH", "/conference/conf1/booking/booking1/tickets", "application/x-www-form-urlencoded", "", []byte(`TicketsBooked=5&CustomerName=Jane+Doe`))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "PATCH", "/conference/conf1/name", "application/x-www-form-urlencoded", tokenForRole(t, "admin"), []byte(`ConferenceName=Boston+2024`))
+	assert.Equalf(t, 200, code, body)
+
+	conference, _ := database.GetConference("conf1")
+	assert.Equal(t, "Boston 2024", conference.ConferenceName)
+	assert.Equal(t, uint(5), conference.Bookings[0].TicketsBooked)
+	assert.Equal(t, "Roman Bauer", conference.Bookings[0].CustomerName, "the alias only changes its field")
+}
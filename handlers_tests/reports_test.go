@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalesReports(t *testing.T) {
+	app := setupTestApp(t, attendeesTestConferences())
+	token := tokenForRole(t, "admin")
+
+	var summary struct {
+		TicketsSold     uint    `json:"tickets_sold"`
+		TicketsCanceled uint    `json:"tickets_canceled"`
+		SellThrough     float64 `json:"sell_through_percent"`
+	}
+	code, body := doRequest(t, app, "GET", "/v1/reports/summary", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &summary))
+	assert.Equal(t, uint(43), summary.TicketsSold)
+	assert.Equal(t, uint(5), summary.TicketsCanceled)
+	assert.Equal(t, 28.67, summary.SellThrough)
+
+	var series []map[string]interface{}
+	code, body = doRequest(t, app, "GET", "/v1/reports/conferences/conf1/daily?from=2022-11-03&to=2022-11-04&tz=Europe/Berlin", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &series))
+	if assert.Len(t, series, 2) {
+		assert.Equal(t, float64(2), series[1]["bookings"])
+		assert.Equal(t, float64(1), series[1]["cancellations"])
+	}
+
+	code, _ = doRequest(t, app, "GET", "/v1/reports/conferences/unknown", "", token, nil)
+	assert.Equal(t, 404, code)
+	code, _ = doRequest(t, app, "GET", "/v1/reports/daily?tz=Nowhere", "", token, nil)
+	assert.Equal(t, 400, code)
+	code, _ = doRequest(t, app, "GET", "/v1/reports/summary", "", tokenForRole(t, "organizer"), nil)
+	assert.Equal(t, 401, code)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/live"
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var publishCommits sync.Once
+
+// readStreamEvent returns the next event of the stream without comments and retry hints.
+func readStreamEvent(t *testing.T, reader *bufio.Reader) string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(lines) > 0 {
+				return strings.Join(lines, "\n")
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, ":") && !strings.HasPrefix(line, "retry:") {
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestConferenceStream(t *testing.T) {
+	prevHeartbeat := config.Current.Stream.HeartbeatInterval
+	config.Current.Stream.HeartbeatInterval = 20 * time.Millisecond
+	t.Cleanup(func() { config.Current.Stream.HeartbeatInterval = prevHeartbeat })
+	publishCommits.Do(func() { database.OnCommit(live.Default.PublishCommit) })
+	app := setupTestApp(t, testConferences())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+	streamUrl := "http://" + listener.Addr().String() + "/v1/conference/conf1/stream"
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Get(streamUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	reader := bufio.NewReader(res.Body)
+	assert.Equal(t, "id: 2\nevent: remaining_tickets\n"+`data: {"conference_id":"conf1","remaining_tickets":110,"total_tickets":150,"sales_status":"on_sale"}`, readStreamEvent(t, reader))
+
+	code, _ := doRequest(t, app, "PATCH", "/v1/conference/conf1/tickets", "application/json", tokenForRole(t, "admin"), []byte(`{"total_tickets": 42}`))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "id: 3\nevent: remaining_tickets\n"+`data: {"conference_id":"conf1","remaining_tickets":2,"total_tickets":42,"sales_status":"low_availability"}`, readStreamEvent(t, reader))
+
+	// heartbeats keep the connection open
+	deadline := time.Now().Add(time.Second)
+	for heartbeat := ""; !strings.HasPrefix(heartbeat, ": heartbeat") && time.Now().Before(deadline); {
+		heartbeat, _ = reader.ReadString('\n')
+	}
+
+	// a client resuming with the latest id gets only new changes
+	req, _ := http.NewRequest("GET", streamUrl, nil)
+	req.Header.Set("Last-Event-ID", "3")
+	resumed, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Body.Close()
+	code, _ = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking1/cancel", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, readStreamEvent(t, bufio.NewReader(resumed.Body)), "id: 4\n")
+
+	code, _ = doRequest(t, app, "GET", "/v1/conference/unknown/stream", "", "", nil)
+	assert.Equal(t, 404, code)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportRows(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	token := tokenForRole(t, "admin")
+
+	invalidCsv := "conference_id,conference_name,total_tickets,customer_name,tickets_booked\n" +
+		"conf1,,,Jane Doe,100\n" +
+		",Oslo 2024,abc,,\n" +
+		"conf1,,,J,20\n"
+	code, body := doRequest(t, app, "POST", "/v1/admin/import", "text/csv", token, []byte(invalidCsv))
+	assert.Equalf(t, 400, code, body)
+	var response struct {
+		Data struct {
+			Errors []struct {
+				Line  int    `json:"line"`
+				Field string `json:"field"`
+			} `json:"errors"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &response))
+	if assert.Len(t, response.Data.Errors, 4) {
+		assert.Equal(t, 3, response.Data.Errors[0].Line)
+		assert.Equal(t, "total_tickets", response.Data.Errors[0].Field)
+		assert.Equal(t, 4, response.Data.Errors[1].Line)
+		assert.Equal(t, "customer_name", response.Data.Errors[1].Field)
+		assert.Equal(t, "tickets_booked", response.Data.Errors[3].Field, "the rows before count against the remaining tickets")
+	}
+
+	validRows := `{"conference_id": "conf1", "customer_name": "Jane Doe", "tickets_booked": 100}
+{"conference_name": "Oslo 2024", "total_tickets": 20}
+`
+	code, body = doRequest(t, app, "POST", "/v1/admin/import?format=jsonl&dry_run=true", "", token, []byte(validRows))
+	assert.Equalf(t, 200, code, body)
+	code, body = doRequest(t, app, "GET", "/v1/conference", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.NotContains(t, body, "Oslo 2024")
+
+	code, body = doRequest(t, app, "POST", "/v1/admin/import", "application/x-ndjson", token, []byte(validRows))
+	assert.Equalf(t, 200, code, body)
+	var conferences []model.Conference
+	code, body = doRequest(t, app, "GET", "/v1/conference", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &conferences))
+	if assert.Len(t, conferences, 2) {
+		assert.Equal(t, uint(10), conferences[0].RemainingTickets)
+		assert.Equal(t, "Oslo 2024", conferences[1].ConferenceName)
+	}
+}
+
+func TestExportRows(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+
+	code, _ := doRequest(t, app, "GET", "/v1/admin/export", "", tokenForRole(t, "customer"), nil)
+	assert.Equal(t, 401, code)
+
+	code, body := doRequest(t, app, "GET", "/v1/admin/export?format=csv", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "conf1,Boston 2023,150,,false,booking1,Roman Bauer,,40,2022-11-02T16:29:20+03:00,2022-11-02T16:29:20+03:00,false,,", lines[1])
+	}
+
+	code, body = doRequest(t, app, "GET", "/v1/admin/export?format=jsonl", "", tokenForRole(t, "admin"), nil)
+	assert.Equal(t, 200, code)
+	assert.True(t, strings.HasPrefix(body, `{"conference_id":"conf1"`), body)
+}
+
+func TestImportKeepsExportedData(t *testing.T) {
+	conferences := testConferences()
+	conferences[0].TotalTickets = 40
+	conferences[0].RemainingTickets = 0
+	conferences[0].StartsAt = "2023-05-02T09:00:00+02:00"
+	conferences[0].Bookings[0].CustomerEmail = "roman@example.com"
+	conferences = append(conferences, model.Conference{Id: "conf2", ConferenceName: "Oslo 2022", TotalTickets: 10, RemainingTickets: 8, IsArchived: true,
+		Bookings: []model.Booking{{Id: "booking3", CustomerName: "Adam Smith", TicketsBooked: 2, BookedAt: "2022-01-02T10:00:00Z", UpdatedAt: "2022-01-02T10:00:00Z"}}})
+	app := setupTestApp(t, conferences)
+	token := tokenForRole(t, "admin")
+
+	// a canceled booking holds no tickets, so it is imported into the sold out conference
+	rows := "conference_id,booking_id,customer_name,customer_email,tickets_booked,is_canceled\n" +
+		"conf1,booking2,Jane Doe,jane@example.com,2,true\n"
+	code, body := doRequest(t, app, "POST", "/v1/admin/import", "text/csv", token, []byte(rows))
+	assert.Equalf(t, 200, code, body)
+
+	// booking ids are unique across conferences
+	rows = "conference_name,total_tickets,booking_id,customer_name,tickets_booked\n" +
+		"Oslo 2024,20,booking1,Adam Smith,1\n"
+	code, body = doRequest(t, app, "POST", "/v1/admin/import", "text/csv", token, []byte(rows))
+	assert.Equalf(t, 400, code, body)
+	assert.Contains(t, body, "booking booking1 already exists in conference conf1")
+
+	code, exported := doRequest(t, app, "GET", "/v1/admin/export?format=jsonl", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, exported, `"customer_email":"roman@example.com"`)
+	assert.Contains(t, exported, `"customer_email":"jane@example.com"`)
+	assert.Contains(t, exported, `"starts_at":"2023-05-02T09:00:00+02:00"`)
+	assert.Contains(t, exported, `"is_archived":true`)
+
+	// the export imports into an empty store without losing data
+	emptyApp := setupTestApp(t, []model.Conference{})
+	code, body = doRequest(t, emptyApp, "POST", "/v1/admin/import", "application/x-ndjson", token, []byte(exported))
+	assert.Equalf(t, 200, code, body)
+	code, reexported := doRequest(t, emptyApp, "GET", "/v1/admin/export?format=jsonl", "", token, nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, exported, reexported)
+	conference, _ := database.GetConference("conf2")
+	assert.True(t, conference.IsArchived)
+
+	// check-ins cannot be imported, the checked-in tickets would be valid again
+	rows = "conference_name,total_tickets,booking_id,customer_name,tickets_booked,check_ins\n" +
+		"Berlin 2024,20,booking4,Adam Smith,2,1 2\n"
+	code, body = doRequest(t, emptyApp, "POST", "/v1/admin/import", "text/csv", token, []byte(rows))
+	assert.Equalf(t, 400, code, body)
+	assert.Contains(t, body, "checked-in tickets would become valid again")
+}
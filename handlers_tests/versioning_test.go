@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"booking-webapp/model"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnversionedRoutesAreDeprecated(t *testing.T) {
+	app := setupTestApp(t, nil)
+
+	req, _ := http.NewRequest("GET", "/v1/hello", nil)
+	res, _ := app.Test(req, -1)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Deprecation"))
+
+	req, _ = http.NewRequest("GET", "/hello", nil)
+	res, _ = app.Test(req, -1)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "true", res.Header.Get("Deprecation"))
+	assert.Equal(t, "Wed, 30 Jun 2027 00:00:00 GMT", res.Header.Get("Sunset"))
+	assert.Equal(t, `</v1/hello>; rel="successor-version"`, res.Header.Get("Link"))
+
+	req, _ = http.NewRequest("POST", "/v1/login", nil)
+	res, _ = app.Test(req, -1)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Deprecation"))
+
+	req, _ = http.NewRequest("GET", "/v1/conference/", nil)
+	res, _ = app.Test(req, -1)
+	assert.Equal(t, 400, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Deprecation"))
+
+	req, _ = http.NewRequest("GET", "/conference/", nil)
+	res, _ = app.Test(req, -1)
+	assert.Equal(t, 400, res.StatusCode)
+	assert.Equal(t, "true", res.Header.Get("Deprecation"))
+}
+
+func TestV1ConferenceShapeIsUnchanged(t *testing.T) {
+	conferences := testConferences()
+	conferences = append(conferences, model.Conference{Id: "conf2", ConferenceName: "Oslo 2022", TotalTickets: 10, RemainingTickets: 10, IsArchived: true})
+	app := setupTestApp(t, conferences)
+
+	// is_archived is only added for archived conferences
+	code, body := doRequest(t, app, "GET", "/v1/conference/conf1", "", tokenForRole(t, "customer"), nil)
+	assert.Equalf(t, 200, code, body)
+	assert.NotContains(t, body, "is_archived")
+	code, body = doRequest(t, app, "GET", "/v1/conference/conf2", "", tokenForRole(t, "customer"), nil)
+	assert.Equalf(t, 200, code, body)
+	assert.Contains(t, body, `"is_archived": true`)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"booking-webapp/outbox"
+	"booking-webapp/scheduler"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	app := setupTestApp(t, testConferences())
+	adminToken := tokenForRole(t, "admin")
+	dispatcher := outbox.NewDispatcher(scheduler.LocalElector{}, outbox.Webhooks{})
+	assert.NoError(t, dispatcher.Poll())
+
+	var mutex sync.Mutex
+	received := []map[string]interface{}{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		event := map[string]interface{}{}
+		json.Unmarshal(body, &event)
+		mutex.Lock()
+		received = append(received, event)
+		mutex.Unlock()
+	}))
+	defer receiver.Close()
+
+	code, body := doRequest(t, app, "POST", "/v1/admin/webhooks", "application/json", adminToken, []byte(`{"url": "ftp://crm", "events": ["booking.deleted"]}`))
+	assert.Equal(t, 400, code)
+	assert.Contains(t, body, "is not a valid http or https URL")
+	assert.Contains(t, body, `unknown event type \"booking.deleted\"`)
+	code, _ = doRequest(t, app, "POST", "/v1/admin/webhooks", "application/json", tokenForRole(t, "organizer"), []byte(`{}`))
+	assert.Equal(t, 401, code)
+
+	var webhook struct {
+		Id     string `json:"id"`
+		Secret string `json:"secret"`
+	}
+	request, _ := json.Marshal(map[string]interface{}{"url": receiver.URL, "events": []string{"booking.created", "booking.canceled"}})
+	code, body = doRequest(t, app, "POST", "/v1/admin/webhooks", "application/json", adminToken, request)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), &webhook))
+	assert.NotEmpty(t, webhook.Secret)
+
+	code, body = doRequest(t, app, "GET", "/v1/admin/webhooks", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, webhook.Id)
+	assert.NotContains(t, body, webhook.Secret)
+
+	code, body = doRequest(t, app, "POST", "/v1/admin/webhooks/"+webhook.Id+"/ping", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `"success": true`)
+
+	code, _ = doRequest(t, app, "POST", "/v1/conference/conf1/booking", "application/json", "", []byte(`{"customer_name": "Jane Doe", "tickets_booked": 2}`))
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking1/name", "application/json", "", []byte(`{"customer_name": "Roman Bauer Jr"}`))
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "PATCH", "/v1/conference/conf1/booking/booking1/cancel", "", "", nil)
+	assert.Equal(t, 200, code)
+	assert.NoError(t, dispatcher.Poll())
+
+	mutex.Lock()
+	types := []interface{}{}
+	for _, event := range received {
+		types = append(types, event["type"])
+	}
+	mutex.Unlock()
+	assert.Equal(t, []interface{}{"ping", "booking.created", "booking.canceled"}, types)
+
+	code, body = doRequest(t, app, "GET", "/v1/admin/webhooks/"+webhook.Id+"/deliveries?limit=2", "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	var deliveries []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &deliveries))
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, "booking.canceled", deliveries[0]["event"])
+	}
+
+	request, _ = json.Marshal(map[string]interface{}{"url": receiver.URL, "events": []string{"*"}})
+	code, body = doRequest(t, app, "PUT", "/v1/admin/webhooks/"+webhook.Id, "application/json", adminToken, request)
+	assert.Equal(t, 200, code)
+	assert.NotContains(t, body, "secret")
+
+	code, _ = doRequest(t, app, "DELETE", "/v1/admin/webhooks/"+webhook.Id, "", adminToken, nil)
+	assert.Equal(t, 200, code)
+	code, _ = doRequest(t, app, "GET", "/v1/admin/webhooks/"+webhook.Id, "", adminToken, nil)
+	assert.Equal(t, 404, code)
+}
@@ -0,0 +1,138 @@
+// Package health tells whether the service can take traffic. The readiness combines the phase of the
+// process, which is only serving between the startup and the shutdown drain, with the checks of the
+// components it depends on, e.g. Mongo, the storage files and the background workers.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phases of the process.
+const (
+	Starting = "starting"
+	Serving  = "serving"
+	Draining = "draining"
+)
+
+// Statuses of the components and of the service.
+const (
+	Up   = "up"
+	Down = "down"
+)
+
+// Check returns an error when the component cannot serve, it has to give up when ctx is done.
+type Check func(ctx context.Context) error
+
+// Component is the outcome of a check.
+type Component struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the readiness of the service, it is up when the process is serving and all components are up.
+type Report struct {
+	Status     string               `json:"status"`
+	Phase      string               `json:"phase"`
+	Components map[string]Component `json:"components"`
+}
+
+// Checker holds the phase and the registered checks.
+type Checker struct {
+	mutex  sync.RWMutex
+	phase  string
+	checks map[string]Check
+}
+
+// Default is the checker of the server.
+var Default = NewChecker()
+
+// NewChecker returns a checker in the starting phase without checks.
+func NewChecker() *Checker {
+	return &Checker{phase: Starting, checks: map[string]Check{}}
+}
+
+// Register adds or replaces the check of a component.
+func (c *Checker) Register(name string, check Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks[name] = check
+}
+
+// SetPhase moves the process to the phase, e.g. to Draining on shutdown.
+func (c *Checker) SetPhase(phase string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.phase = phase
+}
+
+// Phase returns the current phase.
+func (c *Checker) Phase() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.phase
+}
+
+// Ready runs all checks concurrently, each until ctx is done.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mutex.RLock()
+	phase := c.phase
+	names := make([]string, 0, len(c.checks))
+	checks := make([]Check, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checks = append(checks, c.checks[name])
+	}
+	c.mutex.RUnlock()
+
+	components := make([]Component, len(checks))
+	var wait sync.WaitGroup
+	for index, check := range checks {
+		wait.Add(1)
+		go func(index int, check Check) {
+			defer wait.Done()
+			components[index] = run(ctx, check)
+		}(index, check)
+	}
+	wait.Wait()
+
+	report := Report{Status: Up, Phase: phase, Components: map[string]Component{}}
+	if phase != Serving {
+		report.Status = Down
+	}
+	for index, name := range names {
+		report.Components[name] = components[index]
+		if components[index].Status != Up {
+			report.Status = Down
+		}
+	}
+	return report
+}
+
+// run calls the check, a check which does not return when ctx is done is reported down.
+func run(ctx context.Context, check Check) Component {
+	started := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	component := Component{Status: Up, DurationMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		component.Status = Down
+		component.Error = err.Error()
+	}
+	return component
+}
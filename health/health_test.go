@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReady(t *testing.T) {
+	checker := NewChecker()
+	checker.Register("mongo", func(ctx context.Context) error { return nil })
+
+	report := checker.Ready(context.Background())
+	assert.Equal(t, Down, report.Status)
+	assert.Equal(t, Starting, report.Phase)
+	assert.Equal(t, Up, report.Components["mongo"].Status)
+
+	checker.SetPhase(Serving)
+	assert.Equal(t, Up, checker.Ready(context.Background()).Status)
+
+	// a hanging check is down when the context is done, the others are still reported
+	checker.Register("storage", func(ctx context.Context) error { return errors.New("cannot read conferences.json") })
+	checker.Register("outbox", func(ctx context.Context) error { select {} })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	report = checker.Ready(ctx)
+	assert.Equal(t, Down, report.Status)
+	assert.Equal(t, Down, report.Components["storage"].Status)
+	assert.Equal(t, "cannot read conferences.json", report.Components["storage"].Error)
+	assert.Equal(t, "context deadline exceeded", report.Components["outbox"].Error)
+	assert.Equal(t, Up, report.Components["mongo"].Status)
+
+	checker.SetPhase(Draining)
+	checker.Register("storage", func(ctx context.Context) error { return nil })
+	checker.Register("outbox", func(ctx context.Context) error { return nil })
+	assert.Equal(t, Down, checker.Ready(context.Background()).Status)
+}
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	heartbeat := NewHeartbeat()
+	heartbeat.now = func() time.Time { return now }
+
+	assert.EqualError(t, heartbeat.Check(context.Background()), "no round finished yet")
+	heartbeat.Beat(time.Minute, nil)
+	assert.NoError(t, heartbeat.Check(context.Background()))
+
+	heartbeat.Beat(time.Minute, errors.New("disk full"))
+	assert.EqualError(t, heartbeat.Check(context.Background()), "last round failed: disk full")
+
+	heartbeat.Beat(time.Minute, nil)
+	now = now.Add(2 * time.Minute)
+	assert.EqualError(t, heartbeat.Check(context.Background()), "last round finished at 2023-03-01T10:00:00Z, expected every 1m0s")
+
+	heartbeat.Stop()
+	assert.EqualError(t, heartbeat.Check(context.Background()), "stopped")
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Heartbeat tracks the rounds of a background worker. The worker is healthy when its last round
+// succeeded and finished less than maxAge ago, a worker which is stuck or stopped stops beating.
+type Heartbeat struct {
+	mutex   sync.Mutex
+	last    time.Time
+	maxAge  time.Duration
+	err     error
+	stopped bool
+	now     func() time.Time
+}
+
+func NewHeartbeat() *Heartbeat {
+	return &Heartbeat{now: time.Now}
+}
+
+// Beat records a finished round, err is its error. The next round is expected within maxAge.
+func (h *Heartbeat) Beat(maxAge time.Duration, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.last = h.now()
+	h.maxAge = maxAge
+	h.err = err
+	h.stopped = false
+}
+
+// Stop records that the worker ended, e.g. on shutdown.
+func (h *Heartbeat) Stop() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stopped = true
+}
+
+// Check is the health check of the worker.
+func (h *Heartbeat) Check(ctx context.Context) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	switch {
+	case h.stopped:
+		return fmt.Errorf("stopped")
+	case h.last.IsZero():
+		return fmt.Errorf("no round finished yet")
+	case h.now().Sub(h.last) > h.maxAge:
+		return fmt.Errorf("last round finished at %v, expected every %v", h.last.UTC().Format(time.RFC3339), h.maxAge)
+	case h.err != nil:
+		return fmt.Errorf("last round failed: %v", h.err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package integrity
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codes of the invariant violations.
+const (
+	MalformedStore     = "malformed_store"
+	MissingId          = "missing_id"
+	DuplicateId        = "duplicate_id"
+	NegativeCount      = "negative_count"
+	InvalidCount       = "invalid_count"
+	OverflowedCount    = "overflowed_count"
+	CounterDrift       = "counter_drift"
+	OverCapacity       = "over_capacity"
+	MalformedTimestamp = "malformed_timestamp"
+	LedgerDrift        = "ledger_drift"
+)
+
+// maxPlausibleTickets is the largest counter not treated as an overflow.
+const maxPlausibleTickets = math.MaxUint32
+
+// Violation is a broken invariant of the stored conferences. Fixable violations are repaired by Fix.
+type Violation struct {
+	Code         string `json:"code"`
+	ConferenceId string `json:"conference_id,omitempty"`
+	BookingId    string `json:"booking_id,omitempty"`
+	Message      string `json:"message"`
+	Fixable      bool   `json:"fixable"`
+}
+
+type Report struct {
+	Conferences int         `json:"conferences"`
+	Bookings    int         `json:"bookings"`
+	Violations  []Violation `json:"violations"`
+	Fixed       []string    `json:"fixed,omitempty"`
+}
+
+func (report Report) IsHealthy() bool {
+	return len(report.Violations) == 0
+}
+
+// rawConference keeps numbers as written in the store, so hand edits like negative counts can be reported.
+type rawConference struct {
+	Id               string       `json:"id"`
+	ConferenceName   string       `json:"conference_name"`
+	TotalTickets     json.Number  `json:"total_tickets"`
+	RemainingTickets json.Number  `json:"remaining_tickets"`
+	Bookings         []rawBooking `json:"bookings"`
+}
+
+type rawBooking struct {
+	Id            string      `json:"id"`
+	CustomerName  string      `json:"customer_name"`
+	TicketsBooked json.Number `json:"tickets_booked"`
+	BookedAt      string      `json:"booked_at"`
+	UpdatedAt     string      `json:"updated_at"`
+	IsCanceled    bool        `json:"is_canceled"`
+}
+
+// Check scans the conference store and the event log and reports every invariant violation.
+func Check() (Report, error) {
+	report := Report{Violations: []Violation{}}
+
+	storeBytes, err := os.ReadFile(config.LOCAL_DB_PATH)
+	if err != nil {
+		return report, err
+	}
+
+	conferences := []rawConference{}
+	decoder := json.NewDecoder(bytes.NewReader(storeBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&conferences); err != nil {
+		report.add(Violation{Code: MalformedStore, Message: fmt.Sprintf("%v cannot be parsed: %v", config.LOCAL_DB_PATH, err)})
+		return report, nil
+	}
+
+	report.Conferences = len(conferences)
+	conferenceIds := map[string]bool{}
+	bookingIds := map[string]string{}
+	for _, conference := range conferences {
+		report.Bookings += len(conference.Bookings)
+		checkConference(&report, conference, conferenceIds, bookingIds)
+	}
+
+	checkLedger(&report)
+	return report, nil
+}
+
+func checkConference(report *Report, conference rawConference, conferenceIds map[string]bool, bookingIds map[string]string) {
+	confId := conference.Id
+	if confId == "" {
+		report.add(Violation{Code: MissingId, Message: fmt.Sprintf("conference %q has no id", conference.ConferenceName)})
+	} else if conferenceIds[confId] {
+		report.add(Violation{Code: DuplicateId, ConferenceId: confId, Message: fmt.Sprintf("conference id %v is used more than once", confId)})
+	}
+	conferenceIds[confId] = true
+
+	totalTickets, totalOk := report.count(confId, "", "total_tickets", conference.TotalTickets)
+	remainingTickets, remainingOk := report.count(confId, "", "remaining_tickets", conference.RemainingTickets)
+
+	var bookedTickets int64 = 0
+	bookedOk := true
+	for _, booking := range conference.Bookings {
+		if booking.Id == "" {
+			report.add(Violation{Code: MissingId, ConferenceId: confId, Message: fmt.Sprintf("booking of %q has no id", booking.CustomerName)})
+		} else if otherConfId, exists := bookingIds[booking.Id]; exists {
+			report.add(Violation{Code: DuplicateId, ConferenceId: confId, BookingId: booking.Id,
+				Message: fmt.Sprintf("booking id %v is already used in conference %v", booking.Id, otherConfId)})
+		} else {
+			bookingIds[booking.Id] = confId
+		}
+
+		tickets, ticketsOk := report.count(confId, booking.Id, "tickets_booked", booking.TicketsBooked)
+		bookedOk = bookedOk && ticketsOk
+		if !booking.IsCanceled {
+			bookedTickets += tickets
+		}
+
+		bookedAt, bookedAtErr := time.Parse(time.RFC3339, booking.BookedAt)
+		updatedAt, updatedAtErr := time.Parse(time.RFC3339, booking.UpdatedAt)
+		if bookedAtErr != nil {
+			report.add(Violation{Code: MalformedTimestamp, ConferenceId: confId, BookingId: booking.Id,
+				Message: fmt.Sprintf("booked_at %q is not an RFC 3339 timestamp", booking.BookedAt)})
+		}
+		if updatedAtErr != nil {
+			report.add(Violation{Code: MalformedTimestamp, ConferenceId: confId, BookingId: booking.Id,
+				Message: fmt.Sprintf("updated_at %q is not an RFC 3339 timestamp", booking.UpdatedAt)})
+		}
+		if bookedAtErr == nil && updatedAtErr == nil && updatedAt.Before(bookedAt) {
+			report.add(Violation{Code: MalformedTimestamp, ConferenceId: confId, BookingId: booking.Id,
+				Message: fmt.Sprintf("updated_at %v is before booked_at %v", booking.UpdatedAt, booking.BookedAt)})
+		}
+	}
+
+	if !totalOk || !bookedOk {
+		return
+	}
+	if bookedTickets > totalTickets {
+		report.add(Violation{Code: OverCapacity, ConferenceId: confId,
+			Message: fmt.Sprintf("%v tickets booked while the conference has only %v tickets", bookedTickets, totalTickets)})
+		return
+	}
+	if expected := totalTickets - bookedTickets; !remainingOk || remainingTickets != expected {
+		report.add(Violation{Code: CounterDrift, ConferenceId: confId, Fixable: true,
+			Message: fmt.Sprintf("remaining_tickets is %v, expected total_tickets - booked tickets = %v", conference.RemainingTickets, expected)})
+	}
+}
+
+// count parses a stored ticket counter and reports negative, fractional or implausibly large values,
+// the latter usually come from an unsigned underflow.
+func (report *Report) count(confId string, bookingId string, field string, value json.Number) (int64, bool) {
+	violation := Violation{ConferenceId: confId, BookingId: bookingId}
+	number, err := value.Int64()
+	_, uintErr := strconv.ParseUint(value.String(), 10, 64)
+	switch {
+	case strings.HasPrefix(value.String(), "-"):
+		violation.Code = NegativeCount
+		violation.Message = fmt.Sprintf("%v is %v", field, value)
+	case err != nil && uintErr != nil:
+		violation.Code = InvalidCount
+		violation.Message = fmt.Sprintf("%v is %q, not a whole number", field, value)
+	case err != nil || number > maxPlausibleTickets:
+		violation.Code = OverflowedCount
+		violation.Message = fmt.Sprintf("%v is %v, the counter most likely overflowed", field, value)
+		violation.Fixable = field == "remaining_tickets"
+	default:
+		return number, true
+	}
+	report.add(violation)
+	return 0, false
+}
+
+// checkLedger compares the stored conferences with the state replayed from the event log.
+func checkLedger(report *Report) {
+	events, err := database.ReadEvents()
+	if err != nil {
+		report.add(Violation{Code: LedgerDrift, Message: fmt.Sprintf("event log cannot be read: %v", err)})
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	replayed, err := ledger.Replay(events)
+	if err != nil {
+		report.add(Violation{Code: LedgerDrift, Message: fmt.Sprintf("event log cannot be replayed: %v", err)})
+		return
+	}
+	stored, err := database.ReadLocalDB()
+	if err != nil {
+		return
+	}
+
+	replayedById := map[string]model.Conference{}
+	for _, conference := range replayed {
+		replayedById[conference.Id] = conference
+	}
+	for _, conference := range stored {
+		replayedConf, exists := replayedById[conference.Id]
+		delete(replayedById, conference.Id)
+		if !exists {
+			report.add(Violation{Code: LedgerDrift, ConferenceId: conference.Id, Message: "conference is not recorded in the event log"})
+		} else if !reflect.DeepEqual(withoutRemainingTickets(conference), withoutRemainingTickets(replayedConf)) {
+			report.add(Violation{Code: LedgerDrift, ConferenceId: conference.Id, Message: "stored conference differs from the event log, rebuild projections to restore it"})
+		}
+	}
+	for confId := range replayedById {
+		report.add(Violation{Code: LedgerDrift, ConferenceId: confId, Message: "conference from the event log is missing in the store"})
+	}
+}
+
+// withoutRemainingTickets leaves the counter out of the ledger comparison, its drift is reported separately.
+func withoutRemainingTickets(conference model.Conference) model.Conference {
+	conference.RemainingTickets = 0
+	if conference.Bookings == nil {
+		conference.Bookings = []model.Booking{}
+	}
+	return conference
+}
+
+func (report *Report) add(violation Violation) {
+	report.Violations = append(report.Violations, violation)
+}
+
+// Fix recomputes the derived fields of the stored conferences and checks the store again.
+// Other violations need a decision of a human, e.g. which of two duplicate bookings is the right one.
+func Fix() (Report, error) {
+	fixed, err := database.RecomputeRemainingTickets()
+	if err != nil {
+		return Report{}, fmt.Errorf("derived fields cannot be recomputed until the store is corrected by hand: %v", err)
+	}
+
+	report, err := Check()
+	report.Fixed = fixed
+	return report, err
+}
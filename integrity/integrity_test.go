@@ -0,0 +1,70 @@
+package integrity
+
+import (
+	"booking-webapp/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const brokenStore = `[
+	{
+		"id": "conf1",
+		"conference_name": "Boston 2023",
+		"total_tickets": 150,
+		"remaining_tickets": 18446744073709551610,
+		"bookings": [
+			{"id": "b1", "customer_name": "Roman Bauer", "tickets_booked": 40, "booked_at": "2022-11-02T16:29:20+03:00", "updated_at": "2022-11-05T02:08:05+03:00", "is_canceled": false}
+		]
+	},
+	{
+		"id": "conf2",
+		"conference_name": "Summer VIP Boston 2022",
+		"total_tickets": 4,
+		"remaining_tickets": 0,
+		"bookings": [
+			{"id": "b1", "customer_name": "Jane Doe", "tickets_booked": 5, "booked_at": "yesterday", "updated_at": "2022-11-05T02:08:05+03:00", "is_canceled": false}
+		]
+	}
+]`
+
+func useTestStore(t *testing.T, store string) {
+	dir := t.TempDir()
+	prevStore, prevEvents := config.LOCAL_DB_PATH, config.EVENT_LOG_PATH
+	config.LOCAL_DB_PATH = filepath.Join(dir, "conferences.json")
+	config.EVENT_LOG_PATH = filepath.Join(dir, "events.jsonl")
+	t.Cleanup(func() { config.LOCAL_DB_PATH, config.EVENT_LOG_PATH = prevStore, prevEvents })
+
+	if err := os.WriteFile(config.LOCAL_DB_PATH, []byte(store), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func violationCodes(report Report) []string {
+	codes := []string{}
+	for _, violation := range report.Violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func TestCheckReportsViolations(t *testing.T) {
+	useTestStore(t, brokenStore)
+
+	report, err := Check()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Conferences)
+	assert.Equal(t, 2, report.Bookings)
+	assert.ElementsMatch(t, []string{OverflowedCount, CounterDrift, DuplicateId, MalformedTimestamp, OverCapacity}, violationCodes(report))
+}
+
+func TestFixRecomputesRemainingTickets(t *testing.T) {
+	useTestStore(t, brokenStore)
+
+	report, err := Fix()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conf1"}, report.Fixed)
+	assert.ElementsMatch(t, []string{DuplicateId, MalformedTimestamp, OverCapacity}, violationCodes(report))
+}
@@ -0,0 +1,270 @@
+package ledger
+
+import (
+	"booking-webapp/model"
+	"fmt"
+)
+
+// Apply returns the conference state after the event. Remaining tickets are always derived
+// from total tickets and active bookings, they are never stored in the events.
+// A ConferenceCreated event starts from an empty conference, ConferenceDeleted leaves the state
+// unchanged and has to be handled by the caller.
+func Apply(conference model.Conference, event model.Event) (model.Conference, error) {
+	if event.Type != model.EventConferenceCreated && conference.Id != event.ConferenceId {
+		return conference, fmt.Errorf("event %v %v belongs to conference %v, not %v", event.Seq, event.Type, event.ConferenceId, conference.Id)
+	}
+
+	if conference.IsArchived && event.Type != model.EventConferenceDeleted {
+		return conference, fmt.Errorf("conference %v is archived and cannot be changed", conference.Id)
+	}
+
+	switch event.Type {
+	case model.EventConferenceCreated:
+		conference = model.Conference{
+			Id:             event.ConferenceId,
+			ConferenceName: event.ConferenceName,
+			TotalTickets:   event.TotalTickets,
+			StartsAt:       event.StartsAt,
+			Bookings:       []model.Booking{},
+		}
+	case model.EventConferenceRenamed:
+		conference.ConferenceName = event.ConferenceName
+	case model.EventCapacityChanged:
+		conference.TotalTickets = event.TotalTickets
+	case model.EventConferenceScheduled:
+		conference.StartsAt = event.StartsAt
+	case model.EventConferenceArchived:
+		conference.IsArchived = true
+	case model.EventConferenceDeleted:
+	case model.EventBookingCreated:
+		if _, found := findBooking(conference, event.BookingId); found {
+			return conference, fmt.Errorf("booking %v already exists in conference %v", event.BookingId, conference.Id)
+		}
+		conference.Bookings = append(copyBookings(conference.Bookings), model.Booking{
+			Id:            event.BookingId,
+			CustomerName:  event.CustomerName,
+			CustomerEmail: event.CustomerEmail,
+			TicketsBooked: event.TicketsBooked,
+			BookedAt:      event.OccurredAt,
+			UpdatedAt:     event.OccurredAt,
+		})
+	case model.EventCustomerNameChanged, model.EventCustomerEmailChanged, model.EventTicketsChanged, model.EventBookingCanceled:
+		bookingIndex, found := findBooking(conference, event.BookingId)
+		if !found {
+			return conference, fmt.Errorf("no booking with id %v for conference id %v", event.BookingId, conference.Id)
+		}
+		conference.Bookings = copyBookings(conference.Bookings)
+		booking := conference.Bookings[bookingIndex]
+		if booking.IsCanceled {
+			return conference, fmt.Errorf("booking %v is already canceled", booking.Id)
+		}
+		switch event.Type {
+		case model.EventCustomerNameChanged:
+			booking.CustomerName = event.CustomerName
+		case model.EventCustomerEmailChanged:
+			booking.CustomerEmail = event.CustomerEmail
+		case model.EventTicketsChanged:
+			for _, checkIn := range booking.CheckIns {
+				if checkIn.Ticket > event.TicketsBooked {
+					return conference, fmt.Errorf("ticket %v of booking %v is already checked in", checkIn.Ticket, booking.Id)
+				}
+			}
+			booking.TicketVersions = ChangeTicketVersions(booking.TicketVersions, booking.TicketsBooked, event.TicketsBooked)
+			booking.TicketsBooked = event.TicketsBooked
+		case model.EventBookingCanceled:
+			booking.IsCanceled = true
+		}
+		booking.UpdatedAt = event.OccurredAt
+		conference.Bookings[bookingIndex] = booking
+	case model.EventTicketCheckedIn:
+		bookingIndex, found := findBooking(conference, event.BookingId)
+		if !found {
+			return conference, fmt.Errorf("no booking with id %v for conference id %v", event.BookingId, conference.Id)
+		}
+		conference.Bookings = copyBookings(conference.Bookings)
+		booking := conference.Bookings[bookingIndex]
+		if booking.IsCanceled {
+			return conference, fmt.Errorf("booking %v is canceled", booking.Id)
+		}
+		if event.TicketNumber < 1 || event.TicketNumber > booking.TicketsBooked {
+			return conference, fmt.Errorf("booking %v has no ticket %v", booking.Id, event.TicketNumber)
+		}
+		if checkIn, found := FindCheckIn(booking, event.TicketNumber); found {
+			return conference, fmt.Errorf("ticket %v of booking %v is already checked in at %v", event.TicketNumber, booking.Id, checkIn.At)
+		}
+		booking.CheckIns = append(append([]model.TicketCheckIn{}, booking.CheckIns...), model.TicketCheckIn{Ticket: event.TicketNumber, At: event.OccurredAt})
+		conference.Bookings[bookingIndex] = booking
+	default:
+		return conference, fmt.Errorf("unknown event type %v", event.Type)
+	}
+
+	conference.RemainingTickets = 0
+	if bookedTickets := BookedTickets(conference); conference.TotalTickets > bookedTickets {
+		conference.RemainingTickets = conference.TotalTickets - bookedTickets
+	}
+	return conference, nil
+}
+
+// Check verifies the business rules which new events must not break, replay of recorded events skips it.
+func Check(conference model.Conference) error {
+	if bookedTickets := BookedTickets(conference); bookedTickets > conference.TotalTickets {
+		return fmt.Errorf("%v tickets booked while the conference has only %v tickets, overbooking is not supported", bookedTickets, conference.TotalTickets)
+	}
+	return nil
+}
+
+// Replay derives all conferences from the events in the order they were created.
+func Replay(events []model.Event) ([]model.Conference, error) {
+	conferencesById := map[string]model.Conference{}
+	order := []string{}
+
+	for _, event := range events {
+		conference, exists := conferencesById[event.ConferenceId]
+		if !exists && event.Type != model.EventConferenceCreated {
+			return nil, fmt.Errorf("event %v %v refers to unknown conference %v", event.Seq, event.Type, event.ConferenceId)
+		}
+		if event.Type == model.EventConferenceDeleted {
+			delete(conferencesById, event.ConferenceId)
+			continue
+		}
+
+		updated, err := Apply(conference, event)
+		if err != nil {
+			return nil, fmt.Errorf("cannot replay event %v: %v", event.Seq, err)
+		}
+		if !exists {
+			order = append(order, event.ConferenceId)
+		}
+		conferencesById[event.ConferenceId] = updated
+	}
+
+	conferences := []model.Conference{}
+	for _, confId := range order {
+		if conference, exists := conferencesById[confId]; exists {
+			conferences = append(conferences, conference)
+		}
+	}
+	return conferences, nil
+}
+
+// Seed returns the events which lead to the given conference state,
+// used to start the ledger from conferences recorded before it existed.
+// The conference is created at its first booking time or at seededAt if it has no bookings.
+func Seed(conference model.Conference, seededAt string) []model.Event {
+	createdAt := seededAt
+	if len(conference.Bookings) > 0 {
+		createdAt = conference.Bookings[0].BookedAt
+	}
+
+	events := []model.Event{{
+		Type:           model.EventConferenceCreated,
+		OccurredAt:     createdAt,
+		ConferenceId:   conference.Id,
+		ConferenceName: conference.ConferenceName,
+		TotalTickets:   conference.TotalTickets,
+		StartsAt:       conference.StartsAt,
+	}}
+
+	for _, booking := range conference.Bookings {
+		events = append(events, model.Event{
+			Type:          model.EventBookingCreated,
+			OccurredAt:    booking.BookedAt,
+			ConferenceId:  conference.Id,
+			BookingId:     booking.Id,
+			CustomerName:  booking.CustomerName,
+			CustomerEmail: booking.CustomerEmail,
+			TicketsBooked: booking.TicketsBooked,
+		})
+		for _, checkIn := range booking.CheckIns {
+			events = append(events, model.Event{
+				Type:         model.EventTicketCheckedIn,
+				OccurredAt:   checkIn.At,
+				ConferenceId: conference.Id,
+				BookingId:    booking.Id,
+				TicketNumber: checkIn.Ticket,
+			})
+		}
+		if booking.IsCanceled {
+			events = append(events, model.Event{
+				Type:         model.EventBookingCanceled,
+				OccurredAt:   booking.UpdatedAt,
+				ConferenceId: conference.Id,
+				BookingId:    booking.Id,
+			})
+		}
+	}
+
+	if conference.IsArchived {
+		events = append(events, model.Event{
+			Type:         model.EventConferenceArchived,
+			OccurredAt:   seededAt,
+			ConferenceId: conference.Id,
+		})
+	}
+
+	return events
+}
+
+// BookedTickets is the number of tickets in the active bookings of the conference.
+func BookedTickets(conference model.Conference) uint {
+	var bookedTickets uint = 0
+	for _, booking := range conference.Bookings {
+		if !booking.IsCanceled {
+			bookedTickets += booking.TicketsBooked
+		}
+	}
+	return bookedTickets
+}
+
+// FindCheckIn returns the check-in of the ticket if it was admitted already.
+func FindCheckIn(booking model.Booking, ticket uint) (model.TicketCheckIn, bool) {
+	for _, checkIn := range booking.CheckIns {
+		if checkIn.Ticket == ticket {
+			return checkIn, true
+		}
+	}
+	return model.TicketCheckIn{}, false
+}
+
+// TicketVersion returns the version of the ticket, it grows every time the ticket number is issued
+// again after a reduction of the booked tickets, so codes revoked by the reduction stay invalid.
+func TicketVersion(booking model.Booking, ticket uint) uint {
+	if ticket < 1 || int(ticket) > len(booking.TicketVersions) {
+		return 0
+	}
+	return booking.TicketVersions[ticket-1]
+}
+
+// ChangeTicketVersions returns the ticket versions after changing the booked tickets from ticketsBooked
+// to changedTickets. A reduction remembers the revoked numbers, which get the next version when
+// they are booked again.
+func ChangeTicketVersions(versions []uint, ticketsBooked uint, changedTickets uint) []uint {
+	changed := append([]uint{}, versions...)
+	if changedTickets < ticketsBooked {
+		for uint(len(changed)) < ticketsBooked {
+			changed = append(changed, 0)
+		}
+		return changed
+	}
+	for ticket := ticketsBooked + 1; ticket <= changedTickets && int(ticket) <= len(changed); ticket++ {
+		changed[ticket-1]++
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return changed
+}
+
+func findBooking(conference model.Conference, bookingId string) (int, bool) {
+	for bookingIndex, booking := range conference.Bookings {
+		if booking.Id == bookingId {
+			return bookingIndex, true
+		}
+	}
+	return -1, false
+}
+
+// copyBookings keeps the bookings of the previous state untouched when a booking changes.
+func copyBookings(bookings []model.Booking) []model.Booking {
+	return append([]model.Booking{}, bookings...)
+}
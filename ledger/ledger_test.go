@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"booking-webapp/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayDerivesRemainingTickets(t *testing.T) {
+	events := []model.Event{
+		{Seq: 1, Type: model.EventConferenceCreated, ConferenceId: "conf1", ConferenceName: "Boston 2023", TotalTickets: 10},
+		{Seq: 2, Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", CustomerName: "Roman Bauer", TicketsBooked: 4, OccurredAt: "2022-11-02T16:29:20+03:00"},
+		{Seq: 3, Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b2", CustomerName: "Jane Doe", TicketsBooked: 3},
+		{Seq: 4, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 2, OccurredAt: "2022-11-05T02:08:05+03:00"},
+		{Seq: 5, Type: model.EventBookingCanceled, ConferenceId: "conf1", BookingId: "b2"},
+		{Seq: 6, Type: model.EventCapacityChanged, ConferenceId: "conf1", TotalTickets: 20},
+		{Seq: 7, Type: model.EventConferenceCreated, ConferenceId: "conf2", ConferenceName: "Summer VIP Boston 2022", TotalTickets: 40},
+		{Seq: 8, Type: model.EventConferenceDeleted, ConferenceId: "conf2"},
+	}
+
+	conferences, err := Replay(events)
+	assert.NoError(t, err)
+	if assert.Len(t, conferences, 1) {
+		conference := conferences[0]
+		assert.Equal(t, uint(20), conference.TotalTickets)
+		assert.Equal(t, uint(18), conference.RemainingTickets)
+		assert.Equal(t, "2022-11-02T16:29:20+03:00", conference.Bookings[0].BookedAt)
+		assert.Equal(t, "2022-11-05T02:08:05+03:00", conference.Bookings[0].UpdatedAt)
+		assert.True(t, conference.Bookings[1].IsCanceled)
+	}
+}
+
+func TestSeedReplaysToSameState(t *testing.T) {
+	conference := model.Conference{
+		Id:               "conf1",
+		ConferenceName:   "Boston 2023",
+		TotalTickets:     150,
+		RemainingTickets: 110,
+		Bookings: []model.Booking{
+			{Id: "b1", CustomerName: "Roman Bauer", TicketsBooked: 40, BookedAt: "2022-11-02T16:29:20+03:00", UpdatedAt: "2022-11-02T16:29:20+03:00"},
+			{Id: "b2", CustomerName: "Jane Doe", TicketsBooked: 5, BookedAt: "2022-11-03T10:00:00+03:00", UpdatedAt: "2022-11-04T10:00:00+03:00", IsCanceled: true},
+		},
+	}
+
+	conferences, err := Replay(Seed(conference, "2022-12-01T00:00:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Conference{conference}, conferences)
+}
+
+func TestApplyRejectsInconsistentEvents(t *testing.T) {
+	conference, _ := Apply(model.Conference{}, model.Event{Type: model.EventConferenceCreated, ConferenceId: "conf1", TotalTickets: 5})
+
+	_, err := Apply(conference, model.Event{Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "missing", TicketsBooked: 1})
+	assert.Error(t, err)
+
+	overbooked, err := Apply(conference, model.Event{Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), overbooked.RemainingTickets)
+	assert.Error(t, Check(overbooked))
+}
+
+func TestArchivedConferenceIsReadOnly(t *testing.T) {
+	conference, _ := Apply(model.Conference{}, model.Event{Type: model.EventConferenceCreated, ConferenceId: "conf1", TotalTickets: 5})
+	archived, err := Apply(conference, model.Event{Type: model.EventConferenceArchived, ConferenceId: "conf1"})
+	assert.NoError(t, err)
+	assert.True(t, archived.IsArchived)
+
+	_, err = Apply(archived, model.Event{Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 1})
+	assert.Error(t, err)
+
+	conferences, err := Replay(Seed(archived, "2022-12-01T00:00:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Conference{archived}, conferences)
+}
+
+func TestCheckInTickets(t *testing.T) {
+	conference, _ := Replay([]model.Event{
+		{Seq: 1, Type: model.EventConferenceCreated, ConferenceId: "conf1", TotalTickets: 5},
+		{Seq: 2, Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 3, OccurredAt: "2022-11-02T16:29:20+03:00"},
+		{Seq: 3, Type: model.EventTicketCheckedIn, ConferenceId: "conf1", BookingId: "b1", TicketNumber: 2, OccurredAt: "2022-12-01T09:00:00+01:00"},
+	})
+	checkedIn := conference[0]
+	assert.Equal(t, []model.TicketCheckIn{{Ticket: 2, At: "2022-12-01T09:00:00+01:00"}}, checkedIn.Bookings[0].CheckIns)
+	assert.Equal(t, "2022-11-02T16:29:20+03:00", checkedIn.Bookings[0].UpdatedAt)
+
+	for _, event := range []model.Event{
+		{Type: model.EventTicketCheckedIn, ConferenceId: "conf1", BookingId: "b1", TicketNumber: 2},
+		{Type: model.EventTicketCheckedIn, ConferenceId: "conf1", BookingId: "b1", TicketNumber: 4},
+		{Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 1},
+	} {
+		_, err := Apply(checkedIn, event)
+		assert.Errorf(t, err, "%v", event)
+	}
+
+	conferences, err := Replay(Seed(checkedIn, "2022-12-01T00:00:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Conference{checkedIn}, conferences)
+}
+
+func TestTicketsIssuedAgainGetNewVersion(t *testing.T) {
+	conferences, err := Replay([]model.Event{
+		{Seq: 1, Type: model.EventConferenceCreated, ConferenceId: "conf1", TotalTickets: 10},
+		{Seq: 2, Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 3},
+		{Seq: 3, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 1},
+		{Seq: 4, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 2},
+		{Seq: 5, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 4},
+	})
+	assert.NoError(t, err)
+	booking := conferences[0].Bookings[0]
+	assert.Equal(t, []uint{0, 1, 1}, booking.TicketVersions)
+	assert.Equal(t, uint(0), TicketVersion(booking, 1))
+	assert.Equal(t, uint(1), TicketVersion(booking, 3))
+	assert.Equal(t, uint(0), TicketVersion(booking, 4), "ticket 4 is issued for the first time")
+}
@@ -0,0 +1,155 @@
+package live
+
+import (
+	"booking-webapp/model"
+	"sync"
+)
+
+// Types of the feed messages.
+const (
+	FeedSubscribed = "subscribed"
+	FeedChange     = "change"
+	FeedDropped    = "dropped"
+)
+
+// FeedMessage is a message of the activity feed. A change carries the ledger events of one commit
+// and the conference state after it, dropped tells how many messages a slow client missed.
+type FeedMessage struct {
+	Type          string        `json:"type"`
+	Seq           uint64        `json:"seq,omitempty"`
+	ConferenceId  string        `json:"conference_id,omitempty"`
+	Events        []model.Event `json:"events,omitempty"`
+	State         *Update       `json:"state,omitempty"`
+	Dropped       int           `json:"dropped,omitempty"`
+	ConferenceIds []string      `json:"conference_ids,omitempty"`
+}
+
+// FeedSubscription receives the changes of the conferences in its filter, all conferences when
+// the filter is empty.
+type FeedSubscription struct {
+	Messages <-chan FeedMessage
+
+	feed     *Feed
+	messages chan FeedMessage
+	filter   map[string]bool
+}
+
+// Feed fans out every committed change to the activity feed subscribers. Every subscriber has a
+// bounded buffer, when a slow subscriber's buffer is full its pending messages are replaced by a
+// single dropped message, so publishers never wait and the client knows it has to reload.
+type Feed struct {
+	mutex       sync.Mutex
+	subscribers map[*FeedSubscription]bool
+	closed      bool
+}
+
+// Activity is the feed fed by the ledger commits.
+var Activity = NewFeed()
+
+func NewFeed() *Feed {
+	return &Feed{subscribers: map[*FeedSubscription]bool{}}
+}
+
+// Subscribe starts receiving the changes of the conferences, Cancel has to be called when done.
+// buffer is the number of messages kept for the subscriber. Messages is closed when the feed is closed.
+func (f *Feed) Subscribe(buffer int, confIds []string) *FeedSubscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	messages := make(chan FeedMessage, buffer)
+	subscription := &FeedSubscription{Messages: messages, feed: f, messages: messages, filter: confIdSet(confIds)}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		close(messages)
+		return subscription
+	}
+	f.subscribers[subscription] = true
+	return subscription
+}
+
+// Close ends all subscriptions, e.g. on shutdown, later changes are dropped.
+func (f *Feed) Close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closed = true
+	for subscription := range f.subscribers {
+		close(subscription.messages)
+		delete(f.subscribers, subscription)
+	}
+}
+
+// SetFilter replaces the conferences the subscription receives.
+func (s *FeedSubscription) SetFilter(confIds []string) {
+	s.feed.mutex.Lock()
+	defer s.feed.mutex.Unlock()
+	s.filter = confIdSet(confIds)
+}
+
+// Cancel stops the subscription.
+func (s *FeedSubscription) Cancel() {
+	s.feed.mutex.Lock()
+	defer s.feed.mutex.Unlock()
+	delete(s.feed.subscribers, s)
+}
+
+// Publish hands the message to the subscribers of its conference without blocking.
+func (f *Feed) Publish(message FeedMessage) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return
+	}
+	for subscription := range f.subscribers {
+		if len(subscription.filter) > 0 && !subscription.filter[message.ConferenceId] {
+			continue
+		}
+		select {
+		case subscription.messages <- message:
+		default:
+			subscription.overflow()
+		}
+	}
+}
+
+// overflow replaces the pending messages of the subscription with a dropped message counting them
+// and the one which did not fit. The feed is locked, so nothing else is sent in between.
+func (s *FeedSubscription) overflow() {
+	dropped := 1
+	for pending := true; pending; {
+		select {
+		case message := <-s.messages:
+			if message.Type == FeedDropped {
+				dropped += message.Dropped
+			} else {
+				dropped++
+			}
+		default:
+			pending = false
+		}
+	}
+	select {
+	case s.messages <- FeedMessage{Type: FeedDropped, Dropped: dropped}:
+	default:
+	}
+}
+
+// PublishCommit is the ledger commit hook of the feed.
+func (f *Feed) PublishCommit(events []model.Event, conference model.Conference, deleted bool) {
+	last := events[len(events)-1]
+	state := NewUpdate(last.Seq, conference, deleted)
+	state.ConferenceId = last.ConferenceId
+	f.Publish(FeedMessage{Type: FeedChange, Seq: last.Seq, ConferenceId: last.ConferenceId, Events: events, State: &state})
+}
+
+func confIdSet(confIds []string) map[string]bool {
+	set := map[string]bool{}
+	for _, confId := range confIds {
+		if confId != "" {
+			set[confId] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,160 @@
+// Package live fans out changes of the remaining tickets to the open conference streams.
+package live
+
+import (
+	"booking-webapp/model"
+	"sync"
+)
+
+// Sales states of a conference.
+const (
+	OnSale          = "on_sale"
+	LowAvailability = "low_availability"
+	SoldOut         = "sold_out"
+	Archived        = "archived"
+	Deleted         = "deleted"
+)
+
+// Update is the state pushed to the streams. Id is the sequence number of the last event
+// which changed the conference, clients resume from it with Last-Event-ID.
+type Update struct {
+	Id               uint64 `json:"-"`
+	ConferenceId     string `json:"conference_id"`
+	RemainingTickets uint   `json:"remaining_tickets"`
+	TotalTickets     uint   `json:"total_tickets"`
+	SalesStatus      string `json:"sales_status"`
+}
+
+// NewUpdate returns the update for the conference state after event seq.
+func NewUpdate(seq uint64, conference model.Conference, deleted bool) Update {
+	return Update{
+		Id:               seq,
+		ConferenceId:     conference.Id,
+		RemainingTickets: conference.RemainingTickets,
+		TotalTickets:     conference.TotalTickets,
+		SalesStatus:      salesStatus(conference, deleted),
+	}
+}
+
+// salesStatus is low_availability when at most a tenth of the tickets is left.
+func salesStatus(conference model.Conference, deleted bool) string {
+	switch {
+	case deleted:
+		return Deleted
+	case conference.IsArchived:
+		return Archived
+	case conference.RemainingTickets == 0:
+		return SoldOut
+	case conference.RemainingTickets*10 <= conference.TotalTickets:
+		return LowAvailability
+	}
+	return OnSale
+}
+
+// Subscription receives the updates of a conference. Only the latest update is kept for a slow
+// subscriber, so Updates never holds more than one pending update and publishers never wait.
+type Subscription struct {
+	Updates <-chan Update
+
+	hub     *Hub
+	confId  string
+	updates chan Update
+}
+
+// Hub keeps the subscriptions per conference and the last published update to skip repeats.
+type Hub struct {
+	mutex         sync.Mutex
+	subscriptions map[string]map[*Subscription]bool
+	last          map[string]Update
+	closed        bool
+}
+
+// Default is the hub fed by the ledger commits.
+var Default = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{subscriptions: map[string]map[*Subscription]bool{}, last: map[string]Update{}}
+}
+
+// Subscribe starts receiving the updates of the conference, Cancel has to be called when done.
+// Updates is closed when the hub is closed.
+func (h *Hub) Subscribe(confId string) *Subscription {
+	updates := make(chan Update, 1)
+	subscription := &Subscription{Updates: updates, hub: h, confId: confId, updates: updates}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closed {
+		close(updates)
+		return subscription
+	}
+	if h.subscriptions[confId] == nil {
+		h.subscriptions[confId] = map[*Subscription]bool{}
+	}
+	h.subscriptions[confId][subscription] = true
+	return subscription
+}
+
+// Close ends all subscriptions, e.g. on shutdown, later updates are dropped.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.closed = true
+	for confId, subscriptions := range h.subscriptions {
+		for subscription := range subscriptions {
+			close(subscription.updates)
+		}
+		delete(h.subscriptions, confId)
+	}
+}
+
+// Cancel stops the subscription.
+func (s *Subscription) Cancel() {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	delete(s.hub.subscriptions[s.confId], s)
+	if len(s.hub.subscriptions[s.confId]) == 0 {
+		delete(s.hub.subscriptions, s.confId)
+	}
+}
+
+// Publish hands the update to the subscribers of its conference without blocking. Updates which
+// change neither the tickets nor the sales status are skipped.
+func (h *Hub) Publish(update Update) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return
+	}
+	if last, found := h.last[update.ConferenceId]; found && sameState(last, update) {
+		return
+	}
+	if update.SalesStatus == Deleted {
+		delete(h.last, update.ConferenceId)
+	} else {
+		h.last[update.ConferenceId] = update
+	}
+
+	for subscription := range h.subscriptions[update.ConferenceId] {
+		select {
+		case subscription.updates <- update:
+		default:
+			// replace the pending update the subscriber did not pick up yet
+			select {
+			case <-subscription.updates:
+			default:
+			}
+			subscription.updates <- update
+		}
+	}
+}
+
+// PublishCommit is the ledger commit hook of the hub.
+func (h *Hub) PublishCommit(events []model.Event, conference model.Conference, deleted bool) {
+	h.Publish(NewUpdate(events[len(events)-1].Seq, conference, deleted))
+}
+
+func sameState(a Update, b Update) bool {
+	return a.RemainingTickets == b.RemainingTickets && a.TotalTickets == b.TotalTickets && a.SalesStatus == b.SalesStatus
+}
@@ -0,0 +1,81 @@
+package live
+
+import (
+	"booking-webapp/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubKeepsLatestUpdate(t *testing.T) {
+	hub := NewHub()
+	subscription := hub.Subscribe("conf1")
+	defer subscription.Cancel()
+	other := hub.Subscribe("conf2")
+	defer other.Cancel()
+
+	conference := model.Conference{Id: "conf1", TotalTickets: 100, RemainingTickets: 50}
+	hub.Publish(NewUpdate(1, conference, false))
+	conference.RemainingTickets = 5
+	hub.Publish(NewUpdate(2, conference, false))
+	// same tickets and status, e.g. a renamed customer
+	hub.Publish(NewUpdate(3, conference, false))
+
+	update := <-subscription.Updates
+	assert.Equal(t, uint64(2), update.Id)
+	assert.Equal(t, LowAvailability, update.SalesStatus)
+	assert.Len(t, subscription.Updates, 0)
+	assert.Len(t, other.Updates, 0)
+
+	conference.RemainingTickets = 0
+	hub.Publish(NewUpdate(4, conference, false))
+	assert.Equal(t, SoldOut, (<-subscription.Updates).SalesStatus)
+	hub.Publish(NewUpdate(5, conference, true))
+	assert.Equal(t, Deleted, (<-subscription.Updates).SalesStatus)
+}
+
+func TestFeedFiltersAndDropsForSlowSubscribers(t *testing.T) {
+	feed := NewFeed()
+	subscription := feed.Subscribe(2, []string{"conf1"})
+	defer subscription.Cancel()
+
+	for seq := uint64(1); seq <= 4; seq++ {
+		feed.PublishCommit([]model.Event{{Seq: seq, ConferenceId: "conf1"}}, model.Conference{Id: "conf1"}, false)
+	}
+	feed.PublishCommit([]model.Event{{Seq: 5, ConferenceId: "conf2"}}, model.Conference{Id: "conf2"}, false)
+
+	// the buffer of two overflowed at the third change, the fourth fits behind the notice
+	dropped := <-subscription.Messages
+	assert.Equal(t, FeedDropped, dropped.Type)
+	assert.Equal(t, 3, dropped.Dropped)
+	change := <-subscription.Messages
+	assert.Equal(t, FeedChange, change.Type)
+	assert.Equal(t, uint64(4), change.Seq)
+	assert.Len(t, subscription.Messages, 0)
+
+	subscription.SetFilter(nil)
+	feed.PublishCommit([]model.Event{{Seq: 6, ConferenceId: "conf2"}}, model.Conference{Id: "conf2"}, false)
+	assert.Equal(t, "conf2", (<-subscription.Messages).ConferenceId)
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	hub := NewHub()
+	subscription := hub.Subscribe("conf1")
+	feed := NewFeed()
+	feedSubscription := feed.Subscribe(1, nil)
+
+	hub.Close()
+	feed.Close()
+	_, open := <-subscription.Updates
+	assert.False(t, open)
+	_, open = <-feedSubscription.Messages
+	assert.False(t, open)
+
+	// late publishers and subscribers do not panic or wait
+	hub.Publish(NewUpdate(1, model.Conference{Id: "conf1"}, false))
+	feed.PublishCommit([]model.Event{{Seq: 1, ConferenceId: "conf1"}}, model.Conference{Id: "conf1"}, false)
+	_, open = <-hub.Subscribe("conf1").Updates
+	assert.False(t, open)
+	subscription.Cancel()
+	feedSubscription.Cancel()
+}
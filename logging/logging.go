@@ -0,0 +1,236 @@
+// Package logging writes leveled logs as JSON lines, one object per entry with the time, the level,
+// the message and the fields of the entry, e.g. the request id. Fields with secrets, like passwords
+// and tokens, are redacted by their key.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Level is the severity of an entry, entries below the level of the logger are dropped.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the name, debug, info, warn or error.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(level), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+}
+
+// Redacted replaces the values of secret fields.
+const Redacted = "[REDACTED]"
+
+// secretKeys are parts of field names whose values are never logged.
+var secretKeys = []string{"password", "secret", "token", "authorization", "cookie", "connstring", "private_key", "api_key"}
+
+// IsSecret tells whether a field, header or parameter with the name holds a secret.
+func IsSecret(key string) bool {
+	key = strings.ToLower(key)
+	if key == "sign" {
+		return true
+	}
+	for _, secretKey := range secretKeys {
+		if strings.Contains(key, secretKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// sink is shared by a logger and the loggers derived from it.
+type sink struct {
+	mutex sync.Mutex
+	out   io.Writer
+	level Level
+	now   func() time.Time
+}
+
+// Logger writes entries with its fields, which are key value pairs like in Info("booked", "tickets", 2).
+type Logger struct {
+	sink   *sink
+	fields []interface{}
+}
+
+// Default is the logger of the service, writing info and above to stderr.
+var Default = New(os.Stderr, LevelInfo)
+
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{sink: &sink{out: out, level: level, now: time.Now}}
+}
+
+// SetOutput redirects the logger and the loggers derived from it.
+func (l *Logger) SetOutput(out io.Writer) {
+	l.sink.mutex.Lock()
+	defer l.sink.mutex.Unlock()
+	l.sink.out = out
+}
+
+// SetLevel changes the level of the logger and of the loggers derived from it.
+func (l *Logger) SetLevel(level Level) {
+	l.sink.mutex.Lock()
+	defer l.sink.mutex.Unlock()
+	l.sink.level = level
+}
+
+// With returns a logger adding the fields to every entry.
+func (l *Logger) With(keyValues ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyValues))
+	fields = append(append(fields, l.fields...), keyValues...)
+	return &Logger{sink: l.sink, fields: fields}
+}
+
+func (l *Logger) Debug(message string, keyValues ...interface{}) {
+	l.log(LevelDebug, message, keyValues)
+}
+
+func (l *Logger) Info(message string, keyValues ...interface{}) {
+	l.log(LevelInfo, message, keyValues)
+}
+
+func (l *Logger) Warn(message string, keyValues ...interface{}) {
+	l.log(LevelWarn, message, keyValues)
+}
+
+func (l *Logger) Error(message string, keyValues ...interface{}) {
+	l.log(LevelError, message, keyValues)
+}
+
+func (l *Logger) log(level Level, message string, keyValues []interface{}) {
+	l.sink.mutex.Lock()
+	defer l.sink.mutex.Unlock()
+	if level < l.sink.level {
+		return
+	}
+
+	var entry bytes.Buffer
+	entry.WriteString(`{"time":`)
+	writeValue(&entry, l.sink.now().UTC().Format(time.RFC3339Nano))
+	entry.WriteString(`,"level":`)
+	writeValue(&entry, level.String())
+	entry.WriteString(`,"msg":`)
+	writeValue(&entry, message)
+	writeFields(&entry, l.fields)
+	writeFields(&entry, keyValues)
+	entry.WriteString("}\n")
+	l.sink.out.Write(entry.Bytes())
+}
+
+// writeFields appends the key value pairs, a value without a string key is logged under !BADKEY.
+func writeFields(entry *bytes.Buffer, keyValues []interface{}) {
+	for len(keyValues) > 0 {
+		key, ok := keyValues[0].(string)
+		var value interface{}
+		switch {
+		case !ok:
+			key, value, keyValues = "!BADKEY", keyValues[0], keyValues[1:]
+		case len(keyValues) == 1:
+			keyValues = nil
+		default:
+			value, keyValues = keyValues[1], keyValues[2:]
+		}
+
+		if IsSecret(key) {
+			value = Redacted
+		}
+		entry.WriteByte(',')
+		writeValue(entry, key)
+		entry.WriteByte(':')
+		writeValue(entry, redact(value))
+	}
+}
+
+func writeValue(entry *bytes.Buffer, value interface{}) {
+	switch typed := value.(type) {
+	case error:
+		value = typed.Error()
+	case time.Duration:
+		value = typed.String()
+	case fmt.Stringer:
+		value = typed.String()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	entry.Write(encoded)
+}
+
+// redact replaces the secret fields of maps, e.g. a logged request body.
+func redact(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case fiber.Map:
+		return redact(map[string]interface{}(typed))
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(typed))
+		for key, val := range typed {
+			if IsSecret(key) {
+				val = Redacted
+			}
+			redacted[key] = redact(val)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(typed))
+		for key, val := range typed {
+			if IsSecret(key) {
+				val = Redacted
+			}
+			redacted[key] = val
+		}
+		return redacted
+	}
+	return value
+}
+
+// Writer returns a writer logging every line as an entry, it takes the output of the standard log package.
+func (l *Logger) Writer(level Level) io.Writer {
+	return lineWriter{logger: l, level: level}
+}
+
+type lineWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.logger.log(w.level, line, nil)
+	}
+	return len(p), nil
+}
+
+// For returns the logger of the request, which adds its request id, or Default outside of requests.
+func For(c *fiber.Ctx) *Logger {
+	if logger, ok := c.Locals("logger").(*Logger); ok {
+		return logger
+	}
+	return Default
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func entries(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var parsed []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		parsed = append(parsed, entry)
+	}
+	return parsed
+}
+
+func TestLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := New(out, LevelInfo)
+	logger.sink.now = func() time.Time { return time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	requestLogger := logger.With("request_id", "abc")
+	requestLogger.Debug("dropped")
+	requestLogger.Info("booked", "tickets", 2, "error", errors.New("none"), "timeout", time.Second)
+	logger.SetLevel(LevelDebug)
+	requestLogger.Debug("kept", "odd")
+
+	assert.Equal(t, `{"time":"2023-03-01T10:00:00Z","level":"info","msg":"booked","request_id":"abc","tickets":2,"error":"none","timeout":"1s"}`+"\n"+
+		`{"time":"2023-03-01T10:00:00Z","level":"debug","msg":"kept","request_id":"abc","odd":null}`+"\n", out.String())
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := New(out, LevelInfo)
+	logger.Info("login", "password", "hunter2", "access_token", "eyJ", "body", fiber.Map{
+		"login": "admin", "password": "hunter2", "nested": map[string]string{"Authorization": "Bearer eyJ"},
+	})
+	log.New(logger.Writer(LevelWarn), "", 0).Printf("from the standard logger")
+
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "eyJ")
+	logged := entries(t, out)
+	assert.Equal(t, Redacted, logged[0]["password"])
+	assert.Equal(t, map[string]interface{}{
+		"login": "admin", "password": Redacted, "nested": map[string]interface{}{"Authorization": Redacted},
+	}, logged[0]["body"])
+	assert.Equal(t, "warn", logged[1]["level"])
+	assert.Equal(t, "from the standard logger", logged[1]["msg"])
+}
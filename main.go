@@ -1,30 +1,195 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
+	"booking-webapp/config"
 	"booking-webapp/database"
+	"booking-webapp/health"
+	"booking-webapp/live"
+	"booking-webapp/logging"
+	"booking-webapp/metrics"
+	"booking-webapp/outbox"
 	"booking-webapp/router"
+	"booking-webapp/scheduler"
+	"booking-webapp/validation"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
+	// libraries logging through the standard logger end up in the JSON log as well
+	log.SetFlags(0)
+	log.SetOutput(logging.Default.Writer(logging.LevelInfo))
+
+	loaded, err := config.Load("booking-webapp", os.Args[1:], "SIGN", "MONGODB_CONNSTRING")
+	var problems config.Errors
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if errors.As(err, &problems) {
+		logging.Default.Error("cannot start, invalid configuration", "problems", []string(problems))
+		os.Exit(2)
+	} else if err != nil {
+		logging.Default.Error("cannot start", "error", err)
+		os.Exit(2)
+	}
+	// validated by Load
+	level, _ := logging.ParseLevel(loaded.LogLevel)
+	logging.Default.SetLevel(level)
+	if err := validation.LoadRules(); err != nil {
+		logging.Default.Error("cannot start, invalid configuration", "error", err)
+		os.Exit(2)
+	}
+
+	if err := run(loaded.Server); err != nil {
+		logging.Default.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run serves the API until SIGINT or SIGTERM and shuts down gracefully: /readyz fails for SHUTDOWN_DRAIN_DELAY
+// while requests are still served, then no new connections are accepted,
+// running requests like bookings finish, open streams are ended, the background workers finish their
+// current round and Mongo is disconnected. Whatever is left after SHUTDOWN_TIMEOUT is abandoned.
+func run(serverConfig config.Server) error {
+	defer func() {
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := database.Disconnect(disconnectCtx); err != nil {
+			logging.Default.Error("cannot disconnect from the db", "error", err)
+		}
+	}()
+
 	var err error
 	database.UsersCollection, err = database.DBInit("users")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	log.Printf("UsersCollection initialized: %v\n", database.UsersCollection)
 	database.ConferencesCollection, err = database.DBInit("conferences")
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	database.LeasesCollection, err = database.DBInit("leases")
+	if err != nil {
+		return err
+	}
+	database.JobsCollection, err = database.DBInit("jobs")
+	if err != nil {
+		return err
+	}
+
+	logging.Default.Info("connected to mongo", "database", config.MONGODB_DATABASE, "collections", []string{"users", "conferences", "leases", "jobs"})
+
+	if err := database.InitLedger(); err != nil {
+		return err
+	}
+	database.OnCommit(live.Default.PublishCommit)
+	database.OnCommit(live.Activity.PublishCommit)
+	database.OnCommit(metrics.RecordCommit)
+	conferences, err := database.ReadLocalDB()
+	if err != nil {
+		return err
 	}
-	log.Printf("ConferencesCollection initialized: %v\n", database.ConferencesCollection)
+	metrics.SetConferences(conferences)
 
-	app := fiber.New()
+	stopWorkers := make(chan struct{})
+	outboxDone, err := outbox.Start(stopWorkers)
+	if err != nil {
+		return err
+	}
+	schedulerDone, err := scheduler.Start(stopWorkers)
+	if err != nil {
+		close(stopWorkers)
+		return err
+	}
+
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  serverConfig.ReadTimeout,
+		WriteTimeout: serverConfig.WriteTimeout,
+		IdleTimeout:  serverConfig.IdleTimeout,
+		BodyLimit:    serverConfig.BodyLimit,
+		// the start is logged as JSON in OnListen
+		DisableStartupMessage: true,
+	})
 
 	router.SetupRoutes(app)
 
-	app.Listen(":80")
+	health.Default.Register("mongo", database.Ping)
+	health.Default.Register("storage", database.CheckStorage)
+	health.Default.Register("outbox", outbox.Heartbeat.Check)
+	health.Default.Register("scheduler", scheduler.Heartbeat.Check)
+	app.Hooks().OnListen(func() error {
+		logging.Default.Info("listening", "addr", serverConfig.Addr)
+		health.Default.SetPhase(health.Serving)
+		return nil
+	})
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(serverConfig.Addr)
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	var serveErr error
+	select {
+	case serveErr = <-listenErr:
+		if serveErr != nil {
+			serveErr = fmt.Errorf("cannot listen on %v: %v", serverConfig.Addr, serveErr)
+		}
+	case <-signals.Done():
+		// the orchestrator sees the server not ready and stops routing traffic to it before it stops listening
+		health.Default.SetPhase(health.Draining)
+		logging.Default.Info("draining before shutting down", "drain_delay", serverConfig.DrainDelay)
+		select {
+		case <-time.After(serverConfig.DrainDelay):
+		case serveErr = <-listenErr:
+		}
+		logging.Default.Info("shutting down, waiting for running requests", "shutdown_timeout", serverConfig.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	shutdown(shutdownCtx, app, stopWorkers, outboxDone, schedulerDone)
+	return serveErr
+}
+
+// shutdown stops the server and the workers, giving up when ctx is done.
+func shutdown(ctx context.Context, app *fiber.App, stopWorkers chan struct{}, workersDone ...<-chan struct{}) {
+	health.Default.SetPhase(health.Draining)
+	// streams never become idle, they are ended first so the server can drain
+	live.Default.Close()
+	live.Activity.Close()
+
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		if err := app.Shutdown(); err != nil {
+			logging.Default.Error("server shutdown failed", "error", err)
+		}
+	}()
+	close(stopWorkers)
+
+	for _, done := range append(workersDone, serverDone) {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			logging.Default.Warn("shutdown timed out, abandoning running requests and jobs")
+			return
+		}
+	}
+	logging.Default.Info("shutdown complete")
 }
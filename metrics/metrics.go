@@ -0,0 +1,168 @@
+// Package metrics collects Prometheus metrics of the HTTP server, the storage and the bookings,
+// they are served in the text exposition format at /metrics.
+package metrics
+
+import (
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const namespace = "booking"
+
+// Results of a login.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Latency of the storage operations, local files and Mongo.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	bookingsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_created_total",
+		Help:      "Bookings created.",
+	})
+	bookingsCanceled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_canceled_total",
+		Help:      "Bookings canceled.",
+	})
+	ticketsSold = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "conference_tickets_sold",
+		Help:      "Tickets of the active bookings by conference.",
+	}, []string{"conference_id"})
+	ticketsRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "conference_tickets_remaining",
+		Help:      "Remaining tickets by conference.",
+	}, []string{"conference_id"})
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Logins by result and reason, e.g. failure with invalid_password.",
+	}, []string{"result", "reason"})
+)
+
+// Registry holds the metrics of the service and of the Go runtime.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests, requestDuration, storageDuration,
+		bookingsCreated, bookingsCanceled, ticketsSold, ticketsRemaining, logins,
+	)
+}
+
+// Handler serves the metrics.
+func Handler() fiber.Handler {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
+
+// Middleware counts the requests and their latency. Requests are labeled with the route pattern,
+// e.g. /v1/conference/:id, not the path, so ids do not blow up the number of series.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the error handler writes the status after the middlewares
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		// fiber reuses the buffers behind the method and the route after the request
+		route := utils.CopyString(c.Route().Path)
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		if route == "/" && (status == fiber.StatusNotFound || status == fiber.StatusMethodNotAllowed) {
+			// no route matched, the last route is the root middleware
+			route = "unmatched"
+		}
+
+		labels := []string{utils.CopyString(c.Method()), route, strconv.Itoa(status)}
+		requests.WithLabelValues(labels...).Inc()
+		requestDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+		return err
+	}
+}
+
+// ObserveStorage records the latency of a storage operation started at started, it is meant to be deferred.
+func ObserveStorage(operation string, started time.Time) {
+	storageDuration.WithLabelValues(operation).Observe(time.Since(started).Seconds())
+}
+
+// RecordLogin counts a login, reason tells the kind of a successful login or why it failed.
+func RecordLogin(result string, reason string) {
+	logins.WithLabelValues(result, reason).Inc()
+}
+
+// SetConferences sets the ticket gauges of the stored conferences, e.g. on startup.
+func SetConferences(conferences []model.Conference) {
+	for _, conference := range conferences {
+		setTickets(conference)
+	}
+}
+
+// RecordCommit is the ledger commit hook of the business metrics.
+func RecordCommit(events []model.Event, conference model.Conference, deleted bool) {
+	for _, event := range events {
+		switch event.Type {
+		case model.EventBookingCreated:
+			bookingsCreated.Inc()
+		case model.EventBookingCanceled:
+			bookingsCanceled.Inc()
+		}
+	}
+
+	if deleted {
+		ticketsSold.DeleteLabelValues(events[0].ConferenceId)
+		ticketsRemaining.DeleteLabelValues(events[0].ConferenceId)
+		return
+	}
+	setTickets(conference)
+}
+
+func setTickets(conference model.Conference) {
+	ticketsSold.WithLabelValues(conference.Id).Set(float64(ledger.BookedTickets(conference)))
+	ticketsRemaining.WithLabelValues(conference.Id).Set(float64(conference.RemainingTickets))
+}
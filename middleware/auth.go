@@ -17,6 +17,19 @@ func Authorize() fiber.Handler {
 	})
 }
 
+// AuthorizeWebSocket checks the same JWT as Authorize, browsers cannot set headers on
+// WebSocket handshakes and may send it as access_token query parameter instead.
+func AuthorizeWebSocket() fiber.Handler {
+	envval, _ := config.GetSecret("SIGN")
+
+	return jwtware.New(jwtware.Config{
+		SigningKey:   []byte(envval),
+		ErrorHandler: jwtError,
+		ContextKey:   "identity",
+		TokenLookup:  "header:" + fiber.HeaderAuthorization + ",query:access_token",
+	})
+}
+
 func jwtError(c *fiber.Ctx, err error) error {
 	if err.Error() == "Missing or malformed JWT" {
 		return c.Status(fiber.StatusBadRequest).
@@ -25,3 +38,20 @@ func jwtError(c *fiber.Ctx, err error) error {
 	return c.Status(fiber.StatusUnauthorized).
 		JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT", "data": nil})
 }
+
+// Identify reads the JWT the same way as Authorize when one is sent, but lets the
+// requests without a valid token through, e.g. to record who changed public resources.
+func Identify() fiber.Handler {
+	envval, _ := config.GetSecret("SIGN")
+
+	return jwtware.New(jwtware.Config{
+		SigningKey: []byte(envval),
+		ContextKey: "identity",
+		Filter: func(c *fiber.Ctx) bool {
+			return c.Get(fiber.HeaderAuthorization) == ""
+		},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Next()
+		},
+	})
+}
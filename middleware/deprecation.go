@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecated marks responses of a deprecated route with Deprecation and Sunset headers
+// and links to the same path under the successor prefix, e.g. /v1.
+func Deprecated(sunset time.Time, successorPrefix string) fiber.Handler {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *fiber.Ctx) error {
+		successorPath := successorPrefix + c.Path()
+		c.Locals("successorPath", successorPath)
+		c.Set("Deprecation", "true")
+		c.Set("Sunset", sunsetHeader)
+		c.Set(fiber.HeaderLink, fmt.Sprintf("<%v>; rel=\"successor-version\"", successorPath))
+		return c.Next()
+	}
+}
+
+// canonicalPath is the path of the request under the current version, so a deprecated alias and its
+// successor are the same resource. Trailing slashes are dropped, the routing ignores them as well.
+func canonicalPath(c *fiber.Ctx) string {
+	path := c.Path()
+	if successorPath, ok := c.Locals("successorPath").(string); ok {
+		path = successorPath
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes retries of a request with the same Idempotency-Key header safe.
+// The first response is stored for the retention window (IDEMPOTENCY_RETENTION, 24h by default)
+// and replayed for the retries, reusing the key for a different request is rejected with 422.
+// Keys are scoped by the user of the JWT, requests without one share the anonymous scope.
+// Server errors are not stored, so such requests can be retried with the same key. A request
+// still in progress blocks its key for at most IDEMPOTENCY_IN_PROGRESS_TIMEOUT (1m by default),
+// so a key is not blocked for the whole retention window when the server crashed meanwhile.
+func Idempotency() fiber.Handler {
+	retention := config.Current.Idempotency.Retention
+	inProgressTimeout := config.Current.Idempotency.InProgressTimeout
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(idempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "incorrect Idempotency-Key header",
+				"data":    "key cannot be longer than 255 characters"})
+		}
+
+		now := time.Now()
+		record := model.IdempotencyRecord{
+			Scope:       idempotencyScope(c),
+			Key:         key,
+			Fingerprint: requestFingerprint(c),
+			CreatedAt:   now.Format(time.RFC3339),
+			ExpiresAt:   now.Add(inProgressTimeout).Format(time.RFC3339),
+		}
+
+		stored, reserveerr := database.ReserveIdempotencyKey(record)
+		if reserveerr != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "server side problem occured while reading idempotency keys from database",
+				"data":    reserveerr})
+		}
+
+		if stored != nil {
+			return replayIdempotentResponse(c, record, *stored)
+		}
+
+		if err := c.Next(); err != nil {
+			database.ReleaseIdempotencyKey(record.Scope, key)
+			return err
+		}
+
+		res := c.Response()
+		if res.StatusCode() >= fiber.StatusInternalServerError {
+			if releaseerr := database.ReleaseIdempotencyKey(record.Scope, key); releaseerr != nil {
+				logging.For(c).Error("cannot release idempotency key", "error", releaseerr)
+			}
+			return nil
+		}
+
+		record.ResponseCode = res.StatusCode()
+		record.ContentType = string(res.Header.ContentType())
+		record.ResponseBody = string(res.Body())
+		record.ExpiresAt = now.Add(retention).Format(time.RFC3339)
+		if completeerr := database.CompleteIdempotencyKey(record); completeerr != nil {
+			logging.For(c).Error("cannot save response for idempotency key", "error", completeerr)
+		}
+		return nil
+	}
+}
+
+func replayIdempotentResponse(c *fiber.Ctx, record model.IdempotencyRecord, stored model.IdempotencyRecord) error {
+	if stored.Fingerprint != record.Fingerprint {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Idempotency-Key is already used for a different request",
+			"data":    nil})
+	}
+
+	if stored.Status != model.IdempotencyCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "request with this Idempotency-Key is still being processed",
+			"data":    nil})
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	c.Set(fiber.HeaderContentType, stored.ContentType)
+	return c.Status(stored.ResponseCode).SendString(stored.ResponseBody)
+}
+
+// idempotencyScope is the caller owning the keys of the request, the user of the JWT or anonymous.
+func idempotencyScope(c *fiber.Ctx) string {
+	token, ok := c.Locals("identity").(*jwt.Token)
+	if !ok {
+		return "anonymous"
+	}
+	username, _ := token.Claims.(jwt.MapClaims)["username"].(string)
+	return "user:" + username
+}
+
+// requestFingerprint identifies the request by method, canonical path and body.
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(canonicalPath(c)))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"booking-webapp/logging"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+// validRequestId limits the ids taken from clients, other ids are replaced.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes the X-Request-ID of the client or generates one, echoes it in the response header and
+// stores it in the "requestid" local, where ledger events and audit entries pick it up, together with
+// a logger adding it to the entries of the request, see logging.For.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestId := c.Get(requestIdHeader)
+		if !validRequestId.MatchString(requestId) {
+			requestId = utils.UUIDv4()
+		} else {
+			requestId = utils.CopyString(requestId)
+		}
+
+		c.Set(requestIdHeader, requestId)
+		c.Locals("requestid", requestId)
+		c.Locals("logger", logging.Default.With("request_id", requestId))
+		return c.Next()
+	}
+}
+
+// AccessLog logs every request when it finished. Errors of the handlers are turned into responses here,
+// so the logged status is the one sent. Bodies are never logged, secret query parameters like the
+// access_token of the feed are redacted.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+		chainErr := c.Next()
+		if chainErr != nil {
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		logger := logging.For(c)
+		log := logger.Info
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log = logger.Error
+		case status >= fiber.StatusBadRequest:
+			log = logger.Warn
+		}
+		fields := []interface{}{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"duration_ms", float64(time.Since(started).Microseconds()) / 1000,
+			"ip", c.IP(),
+		}
+		// reading a streamed body, e.g. of an event stream, would consume it
+		if !c.Response().IsBodyStream() {
+			fields = append(fields, "bytes", len(c.Response().Body()))
+		}
+		if query := redactQuery(string(c.Request().URI().QueryString())); query != "" {
+			fields = append(fields, "query", query)
+		}
+		if chainErr != nil {
+			fields = append(fields, "error", chainErr)
+		}
+		log("request", fields...)
+		return nil
+	}
+}
+
+// redactQuery replaces the values of secret parameters.
+func redactQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "unparsable query"
+	}
+	for key := range values {
+		if logging.IsSecret(key) {
+			values[key] = []string{logging.Redacted}
+		}
+	}
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(logging.Redacted), logging.Redacted)
+}
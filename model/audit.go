@@ -0,0 +1,21 @@
+package model
+
+// AuditEntry is a record of the append-only audit log, one per conference or booking change.
+type AuditEntry struct {
+	Id           string                 `json:"id"`
+	Timestamp    string                 `json:"timestamp"`
+	RequestId    string                 `json:"request_id"`
+	Actor        string                 `json:"actor"`
+	ActorRole    string                 `json:"actor_role"`
+	Action       string                 `json:"action"`
+	Entity       string                 `json:"entity"`
+	EntityId     string                 `json:"entity_id"`
+	ConferenceId string                 `json:"conference_id"`
+	Changes      map[string]FieldChange `json:"changes"`
+}
+
+// FieldChange holds the values of a changed field, Before is nil for created and After for deleted entities.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
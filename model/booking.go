@@ -1,10 +1,21 @@
 package model
 
 type Booking struct {
-	Id            string `json:"id"`
-	CustomerName  string `json:"customer_name"`
-	TicketsBooked uint   `json:"tickets_booked"`
-	BookedAt      string `json:"booked_at"`
-	UpdatedAt     string `json:"updated_at"`
-	IsCanceled    bool   `json:"is_canceled"`
+	Id            string          `json:"id"`
+	CustomerName  string          `json:"customer_name"`
+	CustomerEmail string          `json:"customer_email,omitempty"`
+	TicketsBooked uint            `json:"tickets_booked"`
+	BookedAt      string          `json:"booked_at"`
+	UpdatedAt     string          `json:"updated_at"`
+	IsCanceled    bool            `json:"is_canceled"`
+	CheckIns      []TicketCheckIn `json:"check_ins,omitempty"`
+	// TicketVersions holds the version of the ticket numbers issued again after their revocation,
+	// see ledger.TicketVersion. It is empty as long as no tickets were reduced.
+	TicketVersions []uint `json:"ticket_versions,omitempty"`
+}
+
+// TicketCheckIn records the admission of a ticket, tickets are numbered from 1 to TicketsBooked.
+type TicketCheckIn struct {
+	Ticket uint   `json:"ticket"`
+	At     string `json:"at"`
 }
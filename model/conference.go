@@ -4,6 +4,8 @@ type Conference struct {
 	Id               string    `json:"id"`
 	ConferenceName   string    `json:"conference_name"`
 	TotalTickets     uint      `json:"total_tickets"`
+	StartsAt         string    `json:"starts_at,omitempty"`
 	RemainingTickets uint      `json:"remaining_tickets"`
 	Bookings         []Booking `json:"bookings"`
+	IsArchived       bool      `json:"is_archived,omitempty"`
 }
@@ -0,0 +1,35 @@
+package model
+
+// Types of the booking ledger events.
+const (
+	EventConferenceCreated    = "ConferenceCreated"
+	EventConferenceRenamed    = "ConferenceRenamed"
+	EventCapacityChanged      = "CapacityChanged"
+	EventConferenceScheduled  = "ConferenceScheduled"
+	EventConferenceArchived   = "ConferenceArchived"
+	EventConferenceDeleted    = "ConferenceDeleted"
+	EventBookingCreated       = "BookingCreated"
+	EventCustomerNameChanged  = "CustomerNameChanged"
+	EventCustomerEmailChanged = "CustomerEmailChanged"
+	EventTicketsChanged       = "TicketsChanged"
+	EventBookingCanceled      = "BookingCanceled"
+	EventTicketCheckedIn      = "TicketCheckedIn"
+)
+
+// Event is a record of the append-only booking ledger. Conferences are derived by replaying the events,
+// only the fields relevant for the event type are filled.
+type Event struct {
+	Seq            uint64 `json:"seq"`
+	Type           string `json:"type"`
+	OccurredAt     string `json:"occurred_at"`
+	RequestId      string `json:"request_id,omitempty"`
+	ConferenceId   string `json:"conference_id"`
+	BookingId      string `json:"booking_id,omitempty"`
+	ConferenceName string `json:"conference_name,omitempty"`
+	TotalTickets   uint   `json:"total_tickets,omitempty"`
+	StartsAt       string `json:"starts_at,omitempty"`
+	CustomerName   string `json:"customer_name,omitempty"`
+	CustomerEmail  string `json:"customer_email,omitempty"`
+	TicketsBooked  uint   `json:"tickets_booked,omitempty"`
+	TicketNumber   uint   `json:"ticket_number,omitempty"`
+}
@@ -0,0 +1,20 @@
+package model
+
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyRecord keeps the outcome of a request sent with an Idempotency-Key header.
+// Keys are scoped by the caller, e.g. user:jane, so callers cannot see or block each other's keys.
+type IdempotencyRecord struct {
+	Scope        string `json:"scope"`
+	Key          string `json:"key"`
+	Fingerprint  string `json:"fingerprint"`
+	Status       string `json:"status"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	ExpiresAt    string `json:"expires_at"`
+}
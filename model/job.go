@@ -0,0 +1,30 @@
+package model
+
+import "encoding/json"
+
+const (
+	JobScheduled = "scheduled"
+	JobRunning   = "running"
+	JobDone      = "done"
+)
+
+// JobRecord is the stored state of a background job. Recurring jobs have a cron Schedule, one-shot
+// jobs have none and are done after their first successful run. A running job belongs to LeaseOwner
+// until LeaseExpiresAt, afterwards the run counts as crashed and the job is run again.
+type JobRecord struct {
+	Name           string          `json:"name" bson:"_id"`
+	Schedule       string          `json:"schedule,omitempty" bson:"schedule,omitempty"`
+	Status         string          `json:"status" bson:"status"`
+	NextRunAt      string          `json:"next_run_at,omitempty" bson:"next_run_at,omitempty"`
+	LastStartedAt  string          `json:"last_started_at,omitempty" bson:"last_started_at,omitempty"`
+	LastFinishedAt string          `json:"last_finished_at,omitempty" bson:"last_finished_at,omitempty"`
+	LastSuccessAt  string          `json:"last_success_at,omitempty" bson:"last_success_at,omitempty"`
+	LastError      string          `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Failures       int             `json:"failures" bson:"failures"`
+	Runs           int             `json:"runs" bson:"runs"`
+	LeaseOwner     string          `json:"lease_owner,omitempty" bson:"lease_owner,omitempty"`
+	LeaseExpiresAt string          `json:"lease_expires_at,omitempty" bson:"lease_expires_at,omitempty"`
+	State          json.RawMessage `json:"state,omitempty" bson:"state,omitempty"`
+	// Version counts the stored changes, replicas only store a change of the version they read.
+	Version int64 `json:"-" bson:"version"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "encoding/json"
+
+const (
+	OutboxPending = "pending"
+	OutboxDead    = "dead"
+)
+
+// OutboxMessage is a side effect of committed ledger events, e.g. a notification, waiting for delivery.
+// Delivered messages are removed, dead ones stay until they are replayed.
+type OutboxMessage struct {
+	Id            string          `json:"id"`
+	Subscriber    string          `json:"subscriber"`
+	EventSeq      uint64          `json:"event_seq"`
+	ConferenceId  string          `json:"conference_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt string          `json:"next_attempt_at,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+// Outbox holds the undelivered messages. Cursor is the sequence number of the last event
+// whose messages were added, later events have not been looked at yet.
+type Outbox struct {
+	Cursor   uint64          `json:"cursor"`
+	Messages []OutboxMessage `json:"messages"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// ConferenceRequest is the client-controlled part of a conference.
+// Fields are pointers so absent fields can be told apart from zero values.
+type ConferenceRequest struct {
+	ConferenceName *string `json:"conference_name" validate:"required,minlen=name_min_length,maxlen=conference_name_max_length,chars=name_chars"`
+	TotalTickets   *uint   `json:"total_tickets" validate:"required,min=1,max=max_total_tickets"`
+	StartsAt       *string `json:"starts_at" validate:"omitempty,datetime"`
+}
+
+// BookingRequest is the client-controlled part of a booking.
+type BookingRequest struct {
+	CustomerName  *string `json:"customer_name" validate:"required,minlen=name_min_length,maxlen=customer_name_max_length,chars=name_chars,fullname"`
+	TicketsBooked *uint   `json:"tickets_booked" validate:"required,min=1,max=max_tickets_per_booking"`
+	CustomerEmail *string `json:"customer_email" validate:"omitempty,maxlen=customer_email_max_length,email"`
+}
@@ -2,6 +2,17 @@ package model
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+const (
+	RoleAdmin     = "admin"
+	RoleOrganizer = "organizer"
+	RoleStaff     = "staff"
+	RoleUser      = "user"
+	RoleAnonymous = "anonymous"
+)
+
+// UserRoles are the roles which can be assigned to users.
+var UserRoles = []string{RoleAdmin, RoleOrganizer, RoleStaff, RoleUser}
+
 type UserData struct {
 	Id             primitive.ObjectID `json:"_id" bson:"_id"`
 	Login          string             `json:"login" bson:"login,omitempty"`
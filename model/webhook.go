@@ -0,0 +1,61 @@
+package model
+
+// Types of the events sent to webhooks.
+const (
+	WebhookBookingCreated    = "booking.created"
+	WebhookBookingUpdated    = "booking.updated"
+	WebhookBookingCanceled   = "booking.canceled"
+	WebhookConferenceCreated = "conference.created"
+	WebhookConferenceUpdated = "conference.updated"
+	WebhookConferenceDeleted = "conference.deleted"
+	WebhookPing              = "ping"
+	WebhookAllEvents         = "*"
+)
+
+// WebhookEvents lists the event types a webhook can subscribe to.
+var WebhookEvents = []string{
+	WebhookBookingCreated, WebhookBookingUpdated, WebhookBookingCanceled,
+	WebhookConferenceCreated, WebhookConferenceUpdated, WebhookConferenceDeleted,
+}
+
+// Webhook is a subscription of an external system to the booking events. The secret signs
+// the payloads, it is only shown when it is set.
+type Webhook struct {
+	Id        string   `json:"id"`
+	Url       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// WebhookRequest is the client-controlled part of a webhook. A missing secret is generated.
+type WebhookRequest struct {
+	Url    *string   `json:"url" validate:"required,maxlen=2048,url"`
+	Events *[]string `json:"events"`
+	Secret *string   `json:"secret" validate:"minlen=16,maxlen=256"`
+}
+
+// WebhookDelivery is a record of the delivery log, one per attempt.
+type WebhookDelivery struct {
+	Id          string `json:"id"`
+	WebhookId   string `json:"webhook_id"`
+	Event       string `json:"event"`
+	EventId     string `json:"event_id"`
+	Url         string `json:"url"`
+	Success     bool   `json:"success"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	DeliveredAt string `json:"delivered_at"`
+}
+
+// Subscribes tells whether the webhook wants events of the type.
+func (w Webhook) Subscribes(eventType string) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType || subscribed == WebhookAllEvents {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Package notify emails customers about their bookings. Messages are rendered from per-kind templates
+// and handed to the configured transport, see Configure. The outbox decides when to send them.
+package notify
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"fmt"
+	"sync"
+)
+
+// Kinds of notifications, each has a template of the same name.
+const (
+	BookingConfirmed = "booking_confirmed"
+	BookingChanged   = "booking_changed"
+	BookingCanceled  = "booking_canceled"
+	Reminder         = "conference_reminder"
+)
+
+// Kinds lists every notification kind.
+var Kinds = []string{BookingConfirmed, BookingChanged, BookingCanceled, Reminder}
+
+// Message is a rendered notification. Id is unique per notification, the same id is sent again when
+// a delivery is retried.
+type Message struct {
+	Id      string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages, implemented by the SMTP and log transports.
+type Sender interface {
+	Send(message Message) error
+}
+
+// Data is passed to the templates. Previous is the booking before a change, only set for booking_changed,
+// DaysLeft is the number of days until the conference starts, only set for conference_reminder.
+type Data struct {
+	Conference model.Conference
+	Booking    model.Booking
+	Previous   *model.Booking
+	DaysLeft   int
+}
+
+var (
+	senderMutex sync.Mutex
+	sender      Sender
+	configured  bool
+)
+
+// Configure selects the transport of the configuration, see config.Notify.
+func Configure() (Sender, error) {
+	settings := config.Current.Notify
+	switch settings.Transport {
+	case "smtp":
+		return &SMTPSender{
+			Host:     settings.SMTP.Host,
+			Port:     settings.SMTP.Port,
+			Username: settings.SMTP.Username,
+			Password: settings.SMTP.Password,
+			From:     settings.SMTP.From,
+		}, nil
+	case "log":
+		return LogSender{}, nil
+	case "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown NOTIFY_TRANSPORT %q, use smtp, log or none", settings.Transport)
+}
+
+// SetSender replaces the transport, nil disables notifications.
+func SetSender(s Sender) {
+	senderMutex.Lock()
+	defer senderMutex.Unlock()
+	sender = s
+	configured = true
+}
+
+// CurrentSender returns the transport, configured from the configuration on first use. It is nil
+// when notifications are disabled.
+func CurrentSender() Sender {
+	senderMutex.Lock()
+	defer senderMutex.Unlock()
+	if !configured {
+		configuredSender, err := Configure()
+		if err != nil {
+			logging.Default.Warn("notifications are disabled", "error", err)
+		}
+		sender = configuredSender
+		configured = true
+	}
+	return sender
+}
+
+// Deliver renders the notification for the customer of the booking and sends it.
+func Deliver(s Sender, id string, kind string, data Data) error {
+	message, err := Render(kind, data)
+	if err != nil {
+		return err
+	}
+	message.Id = id
+	message.To = data.Booking.CustomerEmail
+	return s.Send(message)
+}
+
+// LogSender writes messages to the log instead of sending them, useful for development.
+type LogSender struct{}
+
+func (LogSender) Send(message Message) error {
+	logging.Default.Info("notification", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
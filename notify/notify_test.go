@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testData() Data {
+	return Data{
+		Conference: model.Conference{Id: "conf1", ConferenceName: "Boston 2023"},
+		Booking:    model.Booking{Id: "booking1", CustomerName: "Roman Bauer", CustomerEmail: "roman@example.com", TicketsBooked: 2},
+	}
+}
+
+// smtpSink accepts a single SMTP session and returns the received message data.
+func smtpSink(t *testing.T) (int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 sink ready")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "DATA"):
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case strings.HasPrefix(command, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestRenderDefaultTemplates(t *testing.T) {
+	for _, kind := range Kinds {
+		message, err := Render(kind, testData())
+		assert.NoError(t, err, kind)
+		assert.Contains(t, message.Subject, "Boston 2023", kind)
+		assert.Contains(t, message.Body, "Roman Bauer", kind)
+	}
+
+	previous := testData().Booking
+	previous.TicketsBooked = 5
+	data := testData()
+	data.Previous = &previous
+	message, err := Render(BookingChanged, data)
+	assert.NoError(t, err)
+	assert.Contains(t, message.Body, "Tickets: 5 -> 2")
+	assert.NotContains(t, message.Body, "Name:")
+
+	_, err = Render("unknown", testData())
+	assert.Error(t, err)
+}
+
+func TestRenderTemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	prevDir := config.Current.Notify.TemplateDir
+	config.Current.Notify.TemplateDir = dir
+	t.Cleanup(func() { config.Current.Notify.TemplateDir = prevDir })
+	override := `{{define "subject"}}Booked: {{.Booking.Id}}{{end}}{{define "body"}}See you in {{.Conference.ConferenceName}}{{end}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "booking_confirmed.tmpl"), []byte(override), 0644))
+
+	message, err := Render(BookingConfirmed, testData())
+	assert.NoError(t, err)
+	assert.Equal(t, "Booked: booking1", message.Subject)
+	assert.Equal(t, "See you in Boston 2023\n", message.Body)
+
+	message, err = Render(BookingCanceled, testData())
+	assert.NoError(t, err)
+	assert.Contains(t, message.Subject, "canceled")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "booking_changed.tmpl"), []byte(`{{define "body"}}{{end}}`), 0644))
+	_, err = Render(BookingChanged, testData())
+	assert.ErrorContains(t, err, `does not define "subject"`)
+}
+
+func TestSMTPSender(t *testing.T) {
+	port, received := smtpSink(t)
+	sender := &SMTPSender{Host: "127.0.0.1", Port: port, From: "Bookings <bookings@example.com>"}
+
+	assert.NoError(t, Deliver(sender, "42-notifications-1", BookingConfirmed, testData()))
+	data := <-received
+	assert.Contains(t, data, "To: <roman@example.com>\r\n")
+	assert.Contains(t, data, "From: \"Bookings\" <bookings@example.com>\r\n")
+	assert.Contains(t, data, "Subject: Your booking for Boston 2023 is confirmed\r\n")
+	assert.Contains(t, data, "Message-ID: <42-notifications-1@127.0.0.1>\r\n")
+	assert.Contains(t, data, "Hello Roman Bauer,")
+}
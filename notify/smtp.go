@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMTPSender sends messages through an SMTP server. STARTTLS is used when the server offers it,
+// authentication only when a username is set.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(message Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %v", s.From, err)
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %v", message.To, err)
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	address := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	return smtp.SendMail(address, auth, from.Address, []string{to.Address}, s.format(from, to, message))
+}
+
+// format builds the RFC 5322 message, the body is sent as quoted-printable UTF-8 text.
+func (s *SMTPSender) format(from *mail.Address, to *mail.Address, message Message) []byte {
+	messageId := message.Id
+	if messageId == "" {
+		newUuid, _ := uuid.NewRandom()
+		messageId = newUuid.String()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %v\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %v\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%v@%v>\r\n", messageId, s.Host)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	body.Write(bytes.ReplaceAll([]byte(message.Body), []byte("\n"), []byte("\r\n")))
+	body.Close()
+	return buf.Bytes()
+}
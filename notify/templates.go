@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"booking-webapp/config"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Render executes the template of the kind. A template defines a "subject" and a "body" block,
+// a file <kind>.tmpl in NOTIFY_TEMPLATE_DIR replaces the built-in template of the kind.
+func Render(kind string, data Data) (Message, error) {
+	tmpl, err := loadTemplate(kind)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("cannot render subject of %v: %v", kind, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("cannot render body of %v: %v", kind, err)
+	}
+	return Message{Subject: strings.TrimSpace(subject.String()), Body: strings.TrimSpace(body.String()) + "\n"}, nil
+}
+
+func loadTemplate(kind string) (*template.Template, error) {
+	if !isKind(kind) {
+		return nil, fmt.Errorf("unknown notification kind %q", kind)
+	}
+	name := kind + ".tmpl"
+
+	source, err := fs.ReadFile(defaultTemplates, "templates/"+name)
+	if dir := config.Current.Notify.TemplateDir; dir != "" {
+		override, overrideErr := os.ReadFile(filepath.Join(dir, name))
+		if overrideErr == nil {
+			source, err = override, nil
+		} else if !errors.Is(overrideErr, fs.ErrNotExist) {
+			return nil, overrideErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse template %v: %v", name, err)
+	}
+	for _, block := range []string{"subject", "body"} {
+		if tmpl.Lookup(block) == nil {
+			return nil, fmt.Errorf("template %v does not define %q", name, block)
+		}
+	}
+	return tmpl, nil
+}
+
+func isKind(kind string) bool {
+	for _, known := range Kinds {
+		if known == kind {
+			return true
+		}
+	}
+	return false
+}
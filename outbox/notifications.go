@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"booking-webapp/model"
+	"booking-webapp/notify"
+	"encoding/json"
+	"errors"
+)
+
+// notification is the stored payload of a customer notification.
+type notification struct {
+	Kind string      `json:"kind"`
+	Data notify.Data `json:"data"`
+}
+
+// Notifications emails the customers about created, changed and canceled bookings.
+type Notifications struct{}
+
+func (Notifications) Name() string {
+	return "notifications"
+}
+
+// Messages returns a notification per booking of the change which has an email address.
+// Nothing is queued while notifications are disabled.
+func (Notifications) Messages(change Change) ([]interface{}, error) {
+	if notify.CurrentSender() == nil || change.After.Id == "" {
+		return nil, nil
+	}
+
+	// the bookings are not needed by the templates, leaving them out keeps the outbox small
+	conference := change.After
+	conference.Bookings = nil
+
+	payloads := []interface{}{}
+	for _, bookingId := range changedBookings(change.Events) {
+		booking, found := findBooking(change.After, bookingId)
+		if !found || booking.CustomerEmail == "" {
+			continue
+		}
+		previous, existed := findBooking(change.Before, bookingId)
+
+		payload := notification{Data: notify.Data{Conference: conference, Booking: booking}}
+		switch {
+		case !existed:
+			payload.Kind = notify.BookingConfirmed
+		case booking.IsCanceled:
+			payload.Kind = notify.BookingCanceled
+		default:
+			payload.Kind = notify.BookingChanged
+			payload.Data.Previous = &previous
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// Destination is the booking of the message, so its customer gets the notifications in order.
+func (Notifications) Destination(message model.OutboxMessage) string {
+	var payload notification
+	json.Unmarshal(message.Payload, &payload)
+	return payload.Data.Booking.Id
+}
+
+func (Notifications) Deliver(message model.OutboxMessage) error {
+	sender := notify.CurrentSender()
+	if sender == nil {
+		return errors.New("notifications are disabled")
+	}
+
+	var payload notification
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return err
+	}
+	return notify.Deliver(sender, message.Id, payload.Kind, payload.Data)
+}
+
+// changedBookings returns the ids of the bookings changed by the events in order, check-ins are left out.
+func changedBookings(events []model.Event) []string {
+	bookingIds := []string{}
+	for _, event := range events {
+		if event.BookingId == "" || event.Type == model.EventTicketCheckedIn || containsId(bookingIds, event.BookingId) {
+			continue
+		}
+		bookingIds = append(bookingIds, event.BookingId)
+	}
+	return bookingIds
+}
+
+func findBooking(conference model.Conference, bookingId string) (model.Booking, bool) {
+	for _, booking := range conference.Bookings {
+		if booking.Id == bookingId {
+			return booking, true
+		}
+	}
+	return model.Booking{}, false
+}
+
+func containsId(ids []string, id string) bool {
+	for _, known := range ids {
+		if known == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,378 @@
+// Package outbox delivers the side effects of booking changes, e.g. notifications. The event log is
+// written before anything else, so it doubles as the transactional outbox: the dispatcher looks at
+// every committed event, stores the resulting messages together with its cursor and delivers them
+// with retries. Delivery is at least once, subscribers get the message id to detect repeats.
+// With several replicas only the elected leader dispatches.
+package outbox
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/health"
+	"booking-webapp/ledger"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"booking-webapp/scheduler"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Change is a commit of the ledger, the events applied together for a conference in one request,
+// with the conference before and after it. After has an empty id when the conference was deleted.
+type Change struct {
+	Events []model.Event
+	Before model.Conference
+	After  model.Conference
+}
+
+// Subscriber turns changes into messages and delivers them.
+type Subscriber interface {
+	// Name identifies the subscriber in the stored messages.
+	Name() string
+	// Messages returns the payloads to deliver for the change, each is stored as JSON.
+	Messages(change Change) ([]interface{}, error)
+	// Deliver sends a message, an error schedules a retry.
+	Deliver(message model.OutboxMessage) error
+}
+
+// Router is implemented by subscribers which deliver to several destinations, e.g. webhooks.
+// The messages of a destination are delivered in order, different destinations concurrently.
+// A failed message holds back the later messages of its destination until it is delivered or dead.
+// The messages of subscribers without Router share a single destination.
+type Router interface {
+	Destination(message model.OutboxMessage) string
+}
+
+// Dispatcher moves messages from the ledger through the outbox to the subscribers. Concurrency
+// limits the destinations delivered to at the same time. Without Elector the dispatcher always runs.
+type Dispatcher struct {
+	Subscribers  []Subscriber
+	Elector      scheduler.Elector
+	PollInterval time.Duration
+	MaxAttempts  int
+	RetryBase    time.Duration
+	MaxBackoff   time.Duration
+	Concurrency  int
+	Now          func() time.Time
+
+	initialized bool
+	leader      bool
+	replayed    uint64
+	offset      int64
+	conferences map[string]model.Conference
+}
+
+// NewDispatcher returns a dispatcher with the outbox settings of the configuration, see config.Outbox.
+func NewDispatcher(elector scheduler.Elector, subscribers ...Subscriber) *Dispatcher {
+	settings := config.Current.Outbox
+	return &Dispatcher{
+		Subscribers:  subscribers,
+		Elector:      elector,
+		PollInterval: settings.PollInterval,
+		MaxAttempts:  settings.MaxAttempts,
+		RetryBase:    settings.RetryBase,
+		MaxBackoff:   settings.MaxBackoff,
+		Concurrency:  settings.Concurrency,
+		Now:          time.Now,
+	}
+}
+
+// Start runs a dispatcher with the built-in subscribers in the background until stop is closed.
+// The leader is elected through the outbox lease, see scheduler.NewElector.
+// The returned channel is closed when the dispatcher finished its last poll.
+func Start(stop <-chan struct{}) (<-chan struct{}, error) {
+	dispatcher := NewDispatcher(scheduler.NewElector("outbox"), Notifications{}, Webhooks{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(stop)
+	}()
+	return done, nil
+}
+
+// Heartbeat is beaten after every poll of Run, it is the health check of the worker.
+var Heartbeat = health.NewHeartbeat()
+
+// Run polls until stop is closed. Errors are logged and retried with the next poll.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	defer Heartbeat.Stop()
+	for {
+		err := d.Poll()
+		if err != nil {
+			logging.Default.Error("outbox poll failed", "error", err)
+		}
+		Heartbeat.Beat(3*d.PollInterval+time.Minute, err)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll stores the messages of the new events and delivers the due messages once if this process is the leader.
+func (d *Dispatcher) Poll() error {
+	if d.Elector != nil {
+		leader, err := d.Elector.Leader()
+		if err != nil {
+			return fmt.Errorf("cannot elect leader: %v", err)
+		}
+		if leader != d.leader {
+			logging.Default.Info("outbox leadership changed", "leader", leader)
+			d.leader = leader
+		}
+		if !leader {
+			return nil
+		}
+	}
+
+	if err := d.collect(); err != nil {
+		return fmt.Errorf("cannot collect messages: %v", err)
+	}
+	return d.deliver()
+}
+
+// collect replays the events recorded since the last poll into the dispatcher state and enqueues
+// the messages of the changes after the outbox cursor. Only the first poll reads the whole ledger.
+func (d *Dispatcher) collect() error {
+	events, offset, err := database.ReadEventsFrom(d.offset)
+	if err != nil {
+		return err
+	}
+
+	if !d.initialized {
+		var lastSeq uint64 = 0
+		if len(events) > 0 {
+			lastSeq = events[len(events)-1].Seq
+		}
+		if _, err := database.InitOutbox(lastSeq); err != nil {
+			return err
+		}
+		d.conferences = map[string]model.Conference{}
+		d.initialized = true
+	}
+	outbox, err := database.ReadOutbox()
+	if err != nil {
+		return err
+	}
+
+	messages := []model.OutboxMessage{}
+	for _, commit := range commits(events, d.replayed) {
+		change, err := d.apply(commit)
+		if err != nil {
+			return err
+		}
+		if commit[0].Seq <= outbox.Cursor {
+			continue
+		}
+		for _, subscriber := range d.Subscribers {
+			subscriberMessages, err := d.messages(subscriber, change)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, subscriberMessages...)
+		}
+	}
+
+	d.offset = offset
+
+	if d.replayed <= outbox.Cursor {
+		return nil
+	}
+	return database.EnqueueOutboxMessages(d.replayed, messages)
+}
+
+func (d *Dispatcher) apply(commit []model.Event) (Change, error) {
+	confId := commit[0].ConferenceId
+	change := Change{Events: commit, Before: d.conferences[confId]}
+
+	conference := change.Before
+	for _, event := range commit {
+		if event.Type == model.EventConferenceDeleted {
+			conference = model.Conference{}
+			continue
+		}
+		var err error
+		if conference, err = ledger.Apply(conference, event); err != nil {
+			return change, fmt.Errorf("cannot replay event %v: %v", event.Seq, err)
+		}
+	}
+
+	if conference.Id == "" {
+		delete(d.conferences, confId)
+	} else {
+		d.conferences[confId] = conference
+	}
+	change.After = conference
+	d.replayed = commit[len(commit)-1].Seq
+	return change, nil
+}
+
+func (d *Dispatcher) messages(subscriber Subscriber, change Change) ([]model.OutboxMessage, error) {
+	payloads, err := subscriber.Messages(change)
+	if err != nil {
+		return nil, fmt.Errorf("%v cannot handle event %v: %v", subscriber.Name(), change.Events[0].Seq, err)
+	}
+
+	messages := []model.OutboxMessage{}
+	for payloadIndex, payload := range payloads {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, model.OutboxMessage{
+			Id:           fmt.Sprintf("%v-%v-%v", change.Events[0].Seq, subscriber.Name(), payloadIndex+1),
+			Subscriber:   subscriber.Name(),
+			EventSeq:     change.Events[0].Seq,
+			ConferenceId: change.Events[0].ConferenceId,
+			Payload:      payloadBytes,
+			Status:       model.OutboxPending,
+			CreatedAt:    d.Now().Format(time.RFC3339),
+		})
+	}
+	return messages, nil
+}
+
+// deliver sends the pending messages which are due, up to Concurrency destinations at a time.
+// Failed messages are retried with exponential backoff and dead-lettered after MaxAttempts.
+func (d *Dispatcher) deliver() error {
+	outbox, err := database.ReadOutbox()
+	if err != nil {
+		return err
+	}
+
+	// a message waiting for its retry holds back the later messages of its destination
+	now := d.Now()
+	queues := map[string][]model.OutboxMessage{}
+	blocked := map[string]bool{}
+	destinations := []string{}
+	for _, message := range outbox.Messages {
+		if message.Status != model.OutboxPending {
+			continue
+		}
+		destination := d.destination(message)
+		if blocked[destination] {
+			continue
+		}
+		if !isDue(message, now) {
+			blocked[destination] = true
+			continue
+		}
+		if _, found := queues[destination]; !found {
+			destinations = append(destinations, destination)
+		}
+		queues[destination] = append(queues[destination], message)
+	}
+
+	concurrency := d.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	errs := make(chan error, len(destinations))
+	var wg sync.WaitGroup
+	for _, destination := range destinations {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(queue []model.OutboxMessage) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			for _, message := range queue {
+				delivered, err := d.deliverMessage(message, now)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !delivered {
+					return
+				}
+			}
+		}(queues[destination])
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// deliverMessage sends the message and stores the outcome, delivered is false when the delivery failed.
+func (d *Dispatcher) deliverMessage(message model.OutboxMessage, now time.Time) (delivered bool, err error) {
+	deliverErr := fmt.Errorf("unknown subscriber %v", message.Subscriber)
+	if subscriber := d.subscriber(message.Subscriber); subscriber != nil {
+		deliverErr = subscriber.Deliver(message)
+	}
+	if deliverErr == nil {
+		return true, database.UpdateOutboxMessage(message, true)
+	}
+
+	message.Attempts++
+	message.LastError = deliverErr.Error()
+	if message.Attempts >= d.MaxAttempts {
+		message.Status = model.OutboxDead
+		message.NextAttemptAt = ""
+		logging.Default.Warn("outbox message is dead", "message_id", message.Id, "attempts", message.Attempts, "error", deliverErr)
+	} else {
+		message.NextAttemptAt = now.Add(d.backoff(message.Attempts)).Format(time.RFC3339)
+	}
+	return false, database.UpdateOutboxMessage(message, false)
+}
+
+// destination identifies the receiver of the message, see Router.
+func (d *Dispatcher) destination(message model.OutboxMessage) string {
+	if router, ok := d.subscriber(message.Subscriber).(Router); ok {
+		return message.Subscriber + "/" + router.Destination(message)
+	}
+	return message.Subscriber
+}
+
+// backoff doubles the delay with every failed attempt, starting at RetryBase.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.RetryBase
+	for attempt := 1; attempt < attempts && delay < d.MaxBackoff; attempt++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		return d.MaxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) subscriber(name string) Subscriber {
+	for _, subscriber := range d.Subscribers {
+		if subscriber.Name() == name {
+			return subscriber
+		}
+	}
+	return nil
+}
+
+func isDue(message model.OutboxMessage, now time.Time) bool {
+	if message.NextAttemptAt == "" {
+		return true
+	}
+	nextAttemptAt, err := time.Parse(time.RFC3339, message.NextAttemptAt)
+	return err != nil || !nextAttemptAt.After(now)
+}
+
+// commits splits the events after seq into the commits of single requests. Events recorded
+// without request id, e.g. by bookingctl, form a commit each.
+func commits(events []model.Event, seq uint64) [][]model.Event {
+	grouped := [][]model.Event{}
+	for _, event := range events {
+		if event.Seq <= seq {
+			continue
+		}
+		if last := len(grouped) - 1; last >= 0 {
+			previous := grouped[last][len(grouped[last])-1]
+			if event.RequestId != "" && event.RequestId == previous.RequestId && event.ConferenceId == previous.ConferenceId {
+				grouped[last] = append(grouped[last], event)
+				continue
+			}
+		}
+		grouped = append(grouped, []model.Event{event})
+	}
+	return grouped
+}
@@ -0,0 +1,269 @@
+package outbox
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder queues a message per event and fails the deliveries while failures is positive.
+type recorder struct {
+	failures  int
+	delivered []string
+}
+
+func (r *recorder) Name() string {
+	return "recorder"
+}
+
+func (r *recorder) Messages(change Change) ([]interface{}, error) {
+	payloads := []interface{}{}
+	for _, event := range change.Events {
+		payloads = append(payloads, map[string]interface{}{"type": event.Type, "tickets": change.After.RemainingTickets})
+	}
+	return payloads, nil
+}
+
+func (r *recorder) Deliver(message model.OutboxMessage) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("receiver is down")
+	}
+	r.delivered = append(r.delivered, message.Id)
+	return nil
+}
+
+func useTestLedger(t *testing.T) {
+	dir := t.TempDir()
+	for path, name := range map[*string]string{
+		&config.LOCAL_DB_PATH:            "conferences.json",
+		&config.EVENT_LOG_PATH:           "events.jsonl",
+		&config.PROJECTION_POSITION_PATH: "projection_position",
+		&config.OUTBOX_DB_PATH:           "outbox.json",
+	} {
+		path, prevPath := path, *path
+		*path = filepath.Join(dir, name)
+		t.Cleanup(func() { *path = prevPath })
+	}
+
+	_, err := database.ApplyEvents(model.Event{Type: model.EventConferenceCreated, OccurredAt: "2023-01-02T10:00:00Z", ConferenceId: "conf1", ConferenceName: "Boston 2023", TotalTickets: 10})
+	assert.NoError(t, err)
+}
+
+func book(t *testing.T, bookingId string, requestId string) {
+	_, err := database.ApplyEvents(
+		model.Event{Type: model.EventBookingCreated, OccurredAt: "2023-01-02T10:00:00Z", RequestId: requestId, ConferenceId: "conf1", BookingId: bookingId, CustomerName: "Jane Doe", TicketsBooked: 2},
+		model.Event{Type: model.EventCustomerNameChanged, OccurredAt: "2023-01-02T10:00:00Z", RequestId: requestId, ConferenceId: "conf1", BookingId: bookingId, CustomerName: "Jane Roe"},
+	)
+	assert.NoError(t, err)
+}
+
+func testDispatcher(subscriber Subscriber, now *time.Time) *Dispatcher {
+	return &Dispatcher{
+		Subscribers: []Subscriber{subscriber},
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+		MaxBackoff:  90 * time.Second,
+		Now:         func() time.Time { return *now },
+	}
+}
+
+func TestDispatcherDeliversNewEvents(t *testing.T) {
+	useTestLedger(t)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	subscriber := &recorder{}
+	dispatcher := testDispatcher(subscriber, &now)
+
+	// events before the first poll are not delivered
+	assert.NoError(t, dispatcher.Poll())
+	assert.Empty(t, subscriber.delivered)
+
+	book(t, "booking1", "request1")
+	assert.NoError(t, dispatcher.Poll())
+	assert.Equal(t, []string{"2-recorder-1", "2-recorder-2"}, subscriber.delivered)
+
+	// a restarted dispatcher continues at the cursor
+	book(t, "booking2", "")
+	assert.NoError(t, testDispatcher(subscriber, &now).Poll())
+	assert.Equal(t, []string{"2-recorder-1", "2-recorder-2", "4-recorder-1", "5-recorder-1"}, subscriber.delivered)
+
+	outbox, err := database.ReadOutbox()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), outbox.Cursor)
+	assert.Empty(t, outbox.Messages)
+}
+
+func TestDispatcherRetriesAndDeadLetters(t *testing.T) {
+	useTestLedger(t)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	subscriber := &recorder{failures: 3}
+	dispatcher := testDispatcher(subscriber, &now)
+	assert.NoError(t, dispatcher.Poll())
+
+	_, err := database.ApplyEvents(model.Event{Type: model.EventCapacityChanged, OccurredAt: "2023-01-02T10:00:00Z", ConferenceId: "conf1", TotalTickets: 20})
+	assert.NoError(t, err)
+
+	assert.NoError(t, dispatcher.Poll())
+	outbox, _ := database.ReadOutbox()
+	if !assert.Len(t, outbox.Messages, 1) {
+		return
+	}
+	assert.Equal(t, 1, outbox.Messages[0].Attempts)
+	assert.Equal(t, "2023-01-02T10:01:00Z", outbox.Messages[0].NextAttemptAt)
+	assert.Equal(t, "receiver is down", outbox.Messages[0].LastError)
+
+	// not due yet
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, dispatcher.Poll())
+	outbox, _ = database.ReadOutbox()
+	assert.Equal(t, 1, outbox.Messages[0].Attempts)
+
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, dispatcher.Poll())
+	outbox, _ = database.ReadOutbox()
+	assert.Equal(t, 2, outbox.Messages[0].Attempts)
+	assert.Equal(t, "2023-01-02T10:02:30Z", outbox.Messages[0].NextAttemptAt, "backoff is capped")
+
+	now = now.Add(90 * time.Second)
+	assert.NoError(t, dispatcher.Poll())
+	outbox, _ = database.ReadOutbox()
+	assert.Equal(t, model.OutboxDead, outbox.Messages[0].Status)
+	assert.Equal(t, 3, outbox.Messages[0].Attempts)
+
+	replayed, err := database.ReplayOutboxMessages()
+	assert.NoError(t, err)
+	assert.Len(t, replayed, 1)
+	assert.NoError(t, dispatcher.Poll())
+	assert.Equal(t, []string{"2-recorder-1"}, subscriber.delivered)
+}
+
+func TestDispatcherHoldsBackMessagesAfterAFailure(t *testing.T) {
+	useTestLedger(t)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	subscriber := &recorder{failures: 1}
+	dispatcher := testDispatcher(subscriber, &now)
+	assert.NoError(t, dispatcher.Poll())
+
+	book(t, "booking1", "request1")
+	assert.NoError(t, dispatcher.Poll())
+	assert.Empty(t, subscriber.delivered)
+	outbox, _ := database.ReadOutbox()
+	if assert.Len(t, outbox.Messages, 2) {
+		assert.Equal(t, 1, outbox.Messages[0].Attempts)
+		assert.Equal(t, 0, outbox.Messages[1].Attempts, "the second message waits for the first")
+	}
+
+	// not due yet, the second message still waits
+	assert.NoError(t, dispatcher.Poll())
+	assert.Empty(t, subscriber.delivered)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, dispatcher.Poll())
+	assert.Equal(t, []string{"2-recorder-1", "2-recorder-2"}, subscriber.delivered)
+}
+
+// fanout queues a message per destination and records the deliveries of every destination.
+type fanout struct {
+	destinations int
+	release      chan struct{}
+
+	mutex     sync.Mutex
+	active    int
+	maxActive int
+	delivered map[string][]string
+}
+
+func (f *fanout) Name() string {
+	return "fanout"
+}
+
+func (f *fanout) Messages(change Change) ([]interface{}, error) {
+	payloads := []interface{}{}
+	for destination := 1; destination <= f.destinations; destination++ {
+		payloads = append(payloads, fmt.Sprint(destination))
+	}
+	return payloads, nil
+}
+
+func (f *fanout) Destination(message model.OutboxMessage) string {
+	return string(message.Payload)
+}
+
+func (f *fanout) Deliver(message model.OutboxMessage) error {
+	f.mutex.Lock()
+	f.active++
+	if f.active > f.maxActive {
+		f.maxActive = f.active
+	}
+	f.delivered[string(message.Payload)] = append(f.delivered[string(message.Payload)], message.Id)
+	f.mutex.Unlock()
+
+	<-f.release
+
+	f.mutex.Lock()
+	f.active--
+	f.mutex.Unlock()
+	return nil
+}
+
+func TestDispatcherDeliversDestinationsConcurrently(t *testing.T) {
+	useTestLedger(t)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	subscriber := &fanout{destinations: 5, release: make(chan struct{}), delivered: map[string][]string{}}
+	dispatcher := testDispatcher(subscriber, &now)
+	dispatcher.Concurrency = 2
+	assert.NoError(t, dispatcher.Poll())
+
+	book(t, "booking1", "request1")
+	book(t, "booking2", "request2")
+	polled := make(chan error)
+	go func() { polled <- dispatcher.Poll() }()
+	assert.Eventually(t, func() bool {
+		subscriber.mutex.Lock()
+		defer subscriber.mutex.Unlock()
+		return subscriber.active == 2
+	}, time.Second, time.Millisecond)
+	// every destination gets two messages, one after another
+	for delivery := 0; delivery < 10; delivery++ {
+		subscriber.release <- struct{}{}
+	}
+	assert.NoError(t, <-polled)
+
+	assert.Equal(t, 2, subscriber.maxActive)
+	assert.Len(t, subscriber.delivered, 5)
+	assert.Equal(t, []string{"2-fanout-1", "4-fanout-1"}, subscriber.delivered[`"1"`])
+	assert.Equal(t, []string{"2-fanout-5", "4-fanout-5"}, subscriber.delivered[`"5"`])
+}
+
+type follower struct{}
+
+func (follower) Leader() (bool, error) {
+	return false, nil
+}
+
+func TestDispatcherRunsOnlyOnLeader(t *testing.T) {
+	useTestLedger(t)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	subscriber := &recorder{}
+	dispatcher := testDispatcher(subscriber, &now)
+	assert.NoError(t, dispatcher.Poll())
+	book(t, "booking1", "request1")
+
+	dispatcher.Elector = follower{}
+	assert.NoError(t, dispatcher.Poll())
+	assert.Empty(t, subscriber.delivered)
+	outbox, _ := database.ReadOutbox()
+	assert.Equal(t, uint64(1), outbox.Cursor)
+
+	dispatcher.Elector = nil
+	assert.NoError(t, dispatcher.Poll())
+	assert.Equal(t, []string{"2-recorder-1", "2-recorder-2"}, subscriber.delivered)
+}
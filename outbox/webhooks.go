@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/webhooks"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// webhookMessage is the stored payload of a webhook delivery.
+type webhookMessage struct {
+	WebhookId string         `json:"webhook_id"`
+	Event     webhooks.Event `json:"event"`
+}
+
+// Webhooks posts the booking and conference events to the subscribed webhooks.
+type Webhooks struct{}
+
+func (Webhooks) Name() string {
+	return "webhooks"
+}
+
+// Messages returns a message per webhook event of the change and subscribed webhook,
+// so every webhook is retried on its own.
+func (Webhooks) Messages(change Change) ([]interface{}, error) {
+	subscriptions, err := database.ReadWebhooks()
+	if err != nil || len(subscriptions) == 0 {
+		return nil, err
+	}
+
+	events, err := webhookEvents(change)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := []interface{}{}
+	for _, event := range events {
+		for _, webhook := range subscriptions {
+			if webhook.Subscribes(event.Type) {
+				payloads = append(payloads, webhookMessage{WebhookId: webhook.Id, Event: event})
+			}
+		}
+	}
+	return payloads, nil
+}
+
+// Destination is the webhook of the message, every webhook gets its events in order.
+func (Webhooks) Destination(message model.OutboxMessage) string {
+	var payload webhookMessage
+	json.Unmarshal(message.Payload, &payload)
+	return payload.WebhookId
+}
+
+// Deliver posts the event with the current URL and secret of the webhook.
+// Events of deleted webhooks are dropped.
+func (Webhooks) Deliver(message model.OutboxMessage) error {
+	var payload webhookMessage
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return err
+	}
+
+	webhook, err := database.GetWebhook(payload.WebhookId)
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = webhooks.Send(webhook, payload.Event)
+	return err
+}
+
+// webhookEvents translates the ledger events of the change into webhook events. Ids are derived
+// from the first event sequence number, so every webhook gets the same ids.
+func webhookEvents(change Change) ([]webhooks.Event, error) {
+	first := change.Events[0]
+	events := []webhooks.Event{}
+	add := func(eventType string, data interface{}) error {
+		event, err := webhooks.NewEvent(fmt.Sprintf("evt_%v_%v", first.Seq, len(events)+1), eventType, first.OccurredAt, data)
+		events = append(events, event)
+		return err
+	}
+
+	conferenceEvent := ""
+	for _, event := range change.Events {
+		switch event.Type {
+		case model.EventConferenceCreated:
+			conferenceEvent = model.WebhookConferenceCreated
+		case model.EventConferenceDeleted:
+			conferenceEvent = model.WebhookConferenceDeleted
+		case model.EventConferenceRenamed, model.EventCapacityChanged, model.EventConferenceScheduled, model.EventConferenceArchived:
+			if conferenceEvent == "" {
+				conferenceEvent = model.WebhookConferenceUpdated
+			}
+		}
+	}
+	if conferenceEvent != "" {
+		conference := change.After
+		if conferenceEvent == model.WebhookConferenceDeleted {
+			conference = change.Before
+		}
+		conference.Bookings = nil
+		if err := add(conferenceEvent, webhooks.ConferenceData{Conference: conference}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, bookingId := range changedBookings(change.Events) {
+		booking, found := findBooking(change.After, bookingId)
+		if !found {
+			continue
+		}
+		data := webhooks.BookingData{ConferenceId: change.After.Id, ConferenceName: change.After.ConferenceName, Booking: booking}
+		previous, existed := findBooking(change.Before, bookingId)
+
+		eventType := model.WebhookBookingUpdated
+		switch {
+		case !existed:
+			eventType = model.WebhookBookingCreated
+		case booking.IsCanceled:
+			eventType = model.WebhookBookingCanceled
+			data.Previous = &previous
+		default:
+			data.Previous = &previous
+		}
+		if err := add(eventType, data); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,54 @@
+package reporting
+
+import (
+	"booking-webapp/model"
+	"time"
+)
+
+// CheckInStats shows the admission progress of the active bookings of a conference.
+type CheckInStats struct {
+	ConferenceId     string  `json:"conference_id"`
+	ConferenceName   string  `json:"conference_name"`
+	Tickets          uint    `json:"tickets"`
+	CheckedIn        uint    `json:"checked_in"`
+	NotCheckedIn     uint    `json:"not_checked_in"`
+	CheckedInPercent float64 `json:"checked_in_percent"`
+	Bookings         int     `json:"bookings"`
+	BookingsComplete int     `json:"bookings_complete"`
+	BookingsPartial  int     `json:"bookings_partial"`
+	LastCheckInAt    string  `json:"last_check_in_at"`
+}
+
+// CheckIns counts the checked-in tickets of the active bookings.
+func CheckIns(conference model.Conference) CheckInStats {
+	stats := CheckInStats{ConferenceId: conference.Id, ConferenceName: conference.ConferenceName}
+	lastCheckIn := time.Time{}
+
+	for _, booking := range conference.Bookings {
+		if booking.IsCanceled {
+			continue
+		}
+		stats.Bookings++
+		stats.Tickets += booking.TicketsBooked
+		stats.CheckedIn += uint(len(booking.CheckIns))
+
+		switch {
+		case len(booking.CheckIns) == 0:
+		case uint(len(booking.CheckIns)) == booking.TicketsBooked:
+			stats.BookingsComplete++
+		default:
+			stats.BookingsPartial++
+		}
+		for _, checkIn := range booking.CheckIns {
+			at, err := time.Parse(time.RFC3339, checkIn.At)
+			if err == nil && at.After(lastCheckIn) {
+				lastCheckIn = at
+				stats.LastCheckInAt = checkIn.At
+			}
+		}
+	}
+
+	stats.NotCheckedIn = stats.Tickets - stats.CheckedIn
+	stats.CheckedInPercent = percent(stats.CheckedIn, stats.Tickets)
+	return stats
+}
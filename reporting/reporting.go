@@ -0,0 +1,246 @@
+// Package reporting computes sales and occupancy figures from the stored conferences.
+// Reports and time series count bookings by their booked_at time and cancellations by the
+// updated_at time of canceled bookings, which cannot change after the cancellation.
+package reporting
+
+import (
+	"booking-webapp/model"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	_ "time/tzdata"
+)
+
+const dateLayout = "2006-01-02"
+
+// MaxSeriesDays limits the length of daily time series.
+const MaxSeriesDays = 3660
+
+// ErrSeriesTooLong is returned for time series longer than MaxSeriesDays.
+var ErrSeriesTooLong = fmt.Errorf("time series is limited to %v days, narrow down from and to", MaxSeriesDays)
+
+// Period selects the bookings of a report, zero bounds are open. From is inclusive, To exclusive.
+// Location is the timezone of the days in time series.
+type Period struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+// ParsePeriod reads the from, to and tz parameters. from and to are either dates, which
+// are whole days in tz and both inclusive, or RFC 3339 timestamps. tz defaults to UTC.
+func ParsePeriod(from string, to string, tz string) (Period, error) {
+	period := Period{Location: time.UTC}
+	if tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			return Period{}, fmt.Errorf("unknown timezone %q, use IANA names like Europe/Berlin", tz)
+		}
+		period.Location = location
+	}
+
+	var err error
+	if period.From, err = parseBound(from, period.Location, false); err != nil {
+		return Period{}, fmt.Errorf("from %v", err)
+	}
+	if period.To, err = parseBound(to, period.Location, true); err != nil {
+		return Period{}, fmt.Errorf("to %v", err)
+	}
+	if !period.From.IsZero() && !period.To.IsZero() && !period.From.Before(period.To) {
+		return Period{}, fmt.Errorf("from has to be before to")
+	}
+	return period, nil
+}
+
+func parseBound(value string, location *time.Location, isEnd bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		if isEnd {
+			return date.AddDate(0, 0, 1), nil
+		}
+		return date, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("has to be a date like 2022-11-02 or RFC 3339 timestamp, e.g. 2022-11-02T16:29:20+03:00")
+	}
+	return timestamp, nil
+}
+
+func (period Period) contains(timestamp string) bool {
+	at, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return period.From.IsZero() && period.To.IsZero()
+	}
+	return (period.From.IsZero() || !at.Before(period.From)) && (period.To.IsZero() || at.Before(period.To))
+}
+
+// ConferenceReport holds the bookings made and the cancellations done in the period.
+// Remaining tickets are the current ones.
+type ConferenceReport struct {
+	ConferenceId     string  `json:"conference_id"`
+	ConferenceName   string  `json:"conference_name"`
+	TotalTickets     uint    `json:"total_tickets"`
+	TicketsSold      uint    `json:"tickets_sold"`
+	TicketsCanceled  uint    `json:"tickets_canceled"`
+	TicketsRemaining uint    `json:"tickets_remaining"`
+	Bookings         int     `json:"bookings"`
+	Cancellations    int     `json:"cancellations"`
+	SellThrough      float64 `json:"sell_through_percent"`
+	CancellationRate float64 `json:"cancellation_rate_percent"`
+}
+
+// Summary adds up the reports of all conferences.
+type Summary struct {
+	Conferences      int                `json:"conferences"`
+	TotalTickets     uint               `json:"total_tickets"`
+	TicketsSold      uint               `json:"tickets_sold"`
+	TicketsCanceled  uint               `json:"tickets_canceled"`
+	TicketsRemaining uint               `json:"tickets_remaining"`
+	Bookings         int                `json:"bookings"`
+	Cancellations    int                `json:"cancellations"`
+	SellThrough      float64            `json:"sell_through_percent"`
+	CancellationRate float64            `json:"cancellation_rate_percent"`
+	PerConference    []ConferenceReport `json:"per_conference"`
+}
+
+// Day is a point of a daily time series.
+type Day struct {
+	Date            string `json:"date"`
+	Bookings        int    `json:"bookings"`
+	TicketsBooked   uint   `json:"tickets_booked"`
+	Cancellations   int    `json:"cancellations"`
+	TicketsCanceled uint   `json:"tickets_canceled"`
+}
+
+// Conference reports the bookings made and the cancellations done in the period, see the package doc.
+// Tickets sold are the ones of active bookings. Sell-through is the part of the total tickets sold,
+// cancellation rate the part of the tickets sold and canceled which were canceled.
+func Conference(conference model.Conference, period Period) ConferenceReport {
+	report := ConferenceReport{
+		ConferenceId:     conference.Id,
+		ConferenceName:   conference.ConferenceName,
+		TotalTickets:     conference.TotalTickets,
+		TicketsRemaining: conference.RemainingTickets,
+	}
+
+	for _, booking := range conference.Bookings {
+		if period.contains(booking.BookedAt) {
+			report.Bookings++
+			if !booking.IsCanceled {
+				report.TicketsSold += booking.TicketsBooked
+			}
+		}
+		if booking.IsCanceled && period.contains(booking.UpdatedAt) {
+			report.Cancellations++
+			report.TicketsCanceled += booking.TicketsBooked
+		}
+	}
+
+	report.SellThrough = percent(report.TicketsSold, report.TotalTickets)
+	report.CancellationRate = percent(report.TicketsCanceled, report.TicketsSold+report.TicketsCanceled)
+	return report
+}
+
+// Summarize reports every conference and the totals across them.
+func Summarize(conferences []model.Conference, period Period) Summary {
+	summary := Summary{Conferences: len(conferences), PerConference: []ConferenceReport{}}
+	for _, conference := range conferences {
+		report := Conference(conference, period)
+		summary.TotalTickets += report.TotalTickets
+		summary.TicketsSold += report.TicketsSold
+		summary.TicketsCanceled += report.TicketsCanceled
+		summary.TicketsRemaining += report.TicketsRemaining
+		summary.Bookings += report.Bookings
+		summary.Cancellations += report.Cancellations
+		summary.PerConference = append(summary.PerConference, report)
+	}
+
+	summary.SellThrough = percent(summary.TicketsSold, summary.TotalTickets)
+	summary.CancellationRate = percent(summary.TicketsCanceled, summary.TicketsSold+summary.TicketsCanceled)
+	return summary
+}
+
+// Daily counts bookings by booked_at and cancellations by updated_at per day in the timezone
+// of the period. Days without activity between the bounds are included with zero counts,
+// open bounds end at the first and last day with activity.
+func Daily(conferences []model.Conference, period Period) ([]Day, error) {
+	days := map[string]*Day{}
+	dayOf := func(timestamp string) *Day {
+		if !period.contains(timestamp) {
+			return nil
+		}
+		at, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil
+		}
+		date := at.In(period.Location).Format(dateLayout)
+		if days[date] == nil {
+			days[date] = &Day{Date: date}
+		}
+		return days[date]
+	}
+
+	for _, conference := range conferences {
+		for _, booking := range conference.Bookings {
+			if day := dayOf(booking.BookedAt); day != nil {
+				day.Bookings++
+				day.TicketsBooked += booking.TicketsBooked
+			}
+			if !booking.IsCanceled {
+				continue
+			}
+			if day := dayOf(booking.UpdatedAt); day != nil {
+				day.Cancellations++
+				day.TicketsCanceled += booking.TicketsBooked
+			}
+		}
+	}
+
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	first, last := "", ""
+	if len(dates) > 0 {
+		first, last = dates[0], dates[len(dates)-1]
+	}
+	if !period.From.IsZero() {
+		first = period.From.In(period.Location).Format(dateLayout)
+	}
+	if !period.To.IsZero() {
+		last = period.To.Add(-time.Nanosecond).In(period.Location).Format(dateLayout)
+	}
+	if first == "" || last == "" {
+		return []Day{}, nil
+	}
+
+	start, _ := time.Parse(dateLayout, first)
+	end, _ := time.Parse(dateLayout, last)
+	if end.Sub(start) > MaxSeriesDays*24*time.Hour {
+		return nil, ErrSeriesTooLong
+	}
+
+	series := []Day{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := Day{Date: date.Format(dateLayout)}
+		if counted := days[day.Date]; counted != nil {
+			day = *counted
+		}
+		series = append(series, day)
+	}
+	return series, nil
+}
+
+// percent returns part of whole in percent rounded to two decimals, 0 for an empty whole.
+func percent(part uint, whole uint) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
@@ -0,0 +1,76 @@
+package reporting
+
+import (
+	"booking-webapp/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reportTestConference() model.Conference {
+	return model.Conference{
+		Id:               "conf1",
+		ConferenceName:   "Boston 2023",
+		TotalTickets:     30,
+		RemainingTickets: 21,
+		Bookings: []model.Booking{
+			{Id: "b1", TicketsBooked: 4, BookedAt: "2022-11-01T23:30:00Z", UpdatedAt: "2022-11-01T23:30:00Z"},
+			{Id: "b2", TicketsBooked: 2, BookedAt: "2022-11-02T10:00:00Z", UpdatedAt: "2022-11-04T10:00:00Z", IsCanceled: true},
+			{Id: "b3", TicketsBooked: 5, BookedAt: "2022-11-04T12:00:00Z", UpdatedAt: "2022-11-04T12:00:00Z"},
+		},
+	}
+}
+
+func TestConferenceReport(t *testing.T) {
+	report := Conference(reportTestConference(), Period{})
+	assert.Equal(t, uint(9), report.TicketsSold)
+	assert.Equal(t, uint(2), report.TicketsCanceled)
+	assert.Equal(t, uint(21), report.TicketsRemaining)
+	assert.Equal(t, 30.0, report.SellThrough)
+	assert.Equal(t, 18.18, report.CancellationRate)
+
+	period, err := ParsePeriod("2022-11-02", "2022-11-03", "Europe/Berlin")
+	assert.NoError(t, err)
+	report = Conference(reportTestConference(), period)
+	assert.Equal(t, 2, report.Bookings, "b1 is booked on November 2nd in Berlin")
+	assert.Equal(t, uint(4), report.TicketsSold)
+	assert.Equal(t, 0, report.Cancellations, "b2 is canceled on November 4th")
+
+	period, err = ParsePeriod("2022-11-04", "2022-11-04", "")
+	assert.NoError(t, err)
+	report = Conference(reportTestConference(), period)
+	assert.Equal(t, 1, report.Bookings)
+	assert.Equal(t, 1, report.Cancellations)
+	assert.Equal(t, uint(2), report.TicketsCanceled)
+}
+
+func TestDaily(t *testing.T) {
+	period, err := ParsePeriod("", "2022-11-05", "Europe/Berlin")
+	assert.NoError(t, err)
+
+	series, err := Daily([]model.Conference{reportTestConference()}, period)
+	assert.NoError(t, err)
+	assert.Equal(t, []Day{
+		{Date: "2022-11-02", Bookings: 2, TicketsBooked: 6},
+		{Date: "2022-11-03"},
+		{Date: "2022-11-04", Bookings: 1, TicketsBooked: 5, Cancellations: 1, TicketsCanceled: 2},
+		{Date: "2022-11-05"},
+	}, series)
+
+	period, _ = ParsePeriod("2000-01-01", "2022-01-01", "")
+	_, err = Daily(nil, period)
+	assert.ErrorIs(t, err, ErrSeriesTooLong)
+}
+
+func TestParsePeriod(t *testing.T) {
+	_, err := ParsePeriod("2022-11-05", "2022-11-01", "")
+	assert.Error(t, err)
+	_, err = ParsePeriod("yesterday", "", "")
+	assert.Error(t, err)
+	_, err = ParsePeriod("", "", "Mars/Olympus")
+	assert.Error(t, err)
+
+	period, err := ParsePeriod("2022-11-02T16:29:20+03:00", "", "")
+	assert.NoError(t, err)
+	assert.True(t, period.To.IsZero())
+}
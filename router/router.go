@@ -1,38 +1,31 @@
 package router
 
 import (
+	"booking-webapp/config"
 	"booking-webapp/handlers"
+	"booking-webapp/metrics"
 	"booking-webapp/middleware"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func SetupRoutes(app *fiber.App) {
-	api := app.Group("/", logger.New())
-	api.Get("/hello", handlers.GetHello)
-
-	//Login
-	login := api.Group("/login")
-	login.Post("/", handlers.Login)
-
-	//Conference
-	conference := api.Group("/conference")
-	conference.Get("/", middleware.Authorize(), handlers.GetConferences)
-	conference.Get("/:id", middleware.Authorize(), handlers.GetConference)
-	conference.Post("/", middleware.Authorize(), handlers.CreateNewConference)
-	conference.Put("/:id", middleware.Authorize(), handlers.UpdateConference)
-	conference.Patch("/:id/name", middleware.Authorize(), handlers.UpdateConference)
-	conference.Patch("/:id/tickets", middleware.Authorize(), handlers.UpdateConference)
-	conference.Delete("/:id", middleware.Authorize(), handlers.DeleteConference)
-
-	//Booking
-	booking := conference.Group("/:confId/booking")
-	booking.Get("/", middleware.Authorize(), handlers.GetBookings)
-	booking.Get("/:bookingId", handlers.GetBooking)
-	booking.Post("/", handlers.CreateBooking)
-	booking.Put("/:bookingId", handlers.UpdateBooking)
-	booking.Patch("/:bookingId/name", handlers.UpdateBooking)
-	booking.Patch("/:bookingId/tickets", handlers.UpdateBooking)
-	booking.Patch("/:bookingId/cancel", handlers.CancelBooking)
+	//Probes and metrics, registered before the logger and the metrics so polling them does not flood either
+	app.Get("/healthz", handlers.GetHealthz)
+	app.Get("/readyz", handlers.GetReadyz)
+	app.Get("/metrics", metrics.Handler())
+
+	app.Use(metrics.Middleware())
+
+	api := app.Group("/", middleware.RequestID(), middleware.AccessLog())
+
+	//Docs
+	api.Get("/openapi.json", handlers.GetOpenAPI)
+	api.Get("/docs", handlers.GetDocs)
+
+	//Version 1
+	setupV1(api.Group("/v1"))
+
+	//Unversioned aliases of v1, deprecated
+	setupV1(api, middleware.Deprecated(config.Current.API.UnversionedSunset, "/v1"))
 }
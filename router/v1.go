@@ -0,0 +1,90 @@
+package router
+
+import (
+	"booking-webapp/handlers"
+	"booking-webapp/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// setupV1 registers the v1 API on the router. Middlewares are attached to every top level
+// path separately, so the routes can be mounted at the root next to other versions.
+func setupV1(api fiber.Router, middlewares ...fiber.Handler) {
+	api.Get("/hello", append(middlewares, handlers.GetHello)...)
+
+	//Login
+	login := api.Group("/login", middlewares...)
+	login.Post("/", handlers.Login)
+
+	//Conference
+	conference := api.Group("/conference", middlewares...)
+	conference.Get("/", middleware.Authorize(), handlers.GetConferences)
+	conference.Get("/:id", middleware.Authorize(), handlers.GetConference)
+	conference.Post("/", middleware.Authorize(), handlers.CreateNewConference)
+	conference.Put("/:id", middleware.Authorize(), handlers.UpdateConference)
+	conference.Patch("/:id", middleware.Authorize(), handlers.PatchConference)
+	conference.Patch("/:id/name", middleware.Authorize(), handlers.PatchConferenceName)
+	conference.Patch("/:id/tickets", middleware.Authorize(), handlers.PatchConferenceTickets)
+	conference.Delete("/:id", middleware.Authorize(), handlers.DeleteConference)
+	conference.Get("/:id/history", middleware.Authorize(), handlers.GetConferenceHistory)
+	conference.Get("/:id/stream", handlers.StreamConference)
+	conference.Get("/:confId/attendees.csv", middleware.Authorize(), handlers.GetAttendeesCSV)
+	conference.Get("/:confId/attendees.xlsx", middleware.Authorize(), handlers.GetAttendeesXLSX)
+
+	//Booking
+	booking := conference.Group("/:confId/booking")
+	booking.Get("/", middleware.Authorize(), handlers.GetBookings)
+	booking.Get("/:bookingId", middleware.Identify(), handlers.GetBooking)
+	booking.Post("/", middleware.Identify(), middleware.Idempotency(), handlers.CreateBooking)
+	booking.Put("/:bookingId", middleware.Identify(), handlers.UpdateBooking)
+	booking.Patch("/:bookingId", middleware.Identify(), handlers.PatchBooking)
+	booking.Patch("/:bookingId/name", middleware.Identify(), handlers.PatchBookingName)
+	booking.Patch("/:bookingId/tickets", middleware.Identify(), handlers.PatchBookingTickets)
+	booking.Patch("/:bookingId/cancel", middleware.Identify(), handlers.CancelBooking)
+	booking.Get("/:bookingId/tickets", handlers.GetTickets)
+	booking.Get("/:bookingId/tickets/:ticket/qr.png", handlers.GetTicketQRCode)
+
+	//Check-in
+	checkin := api.Group("/checkin", middlewares...)
+	checkin.Post("/", middleware.Authorize(), handlers.CheckIn)
+	conference.Get("/:confId/checkin/stats", middleware.Authorize(), handlers.GetCheckInStats)
+
+	//Tickets
+	ticket := api.Group("/tickets", middlewares...)
+	ticket.Get("/public-key", handlers.GetTicketPublicKey)
+	ticket.Get("/revocations", middleware.Authorize(), handlers.GetTicketRevocations)
+
+	//Audit
+	audit := api.Group("/audit", middlewares...)
+	audit.Get("/", middleware.Authorize(), handlers.GetAuditLog)
+
+	//Reports
+	reports := api.Group("/reports", middlewares...)
+	reports.Get("/summary", middleware.Authorize(), handlers.GetSalesSummary)
+	reports.Get("/daily", middleware.Authorize(), handlers.GetDailySales)
+	reports.Get("/conferences/:confId", middleware.Authorize(), handlers.GetConferenceSales)
+	reports.Get("/conferences/:confId/daily", middleware.Authorize(), handlers.GetConferenceDailySales)
+
+	//Admin
+	admin := api.Group("/admin", middlewares...)
+	admin.Post("/projections/rebuild", middleware.Authorize(), handlers.RebuildProjections)
+	admin.Get("/integrity", middleware.Authorize(), handlers.CheckIntegrity)
+	admin.Post("/integrity/fix", middleware.Authorize(), handlers.FixIntegrity)
+	admin.Post("/import", middleware.Authorize(), handlers.ImportData)
+	admin.Get("/export", middleware.Authorize(), handlers.ExportData)
+	admin.Get("/outbox", middleware.Authorize(), handlers.GetOutbox)
+	admin.Post("/outbox/replay", middleware.Authorize(), handlers.ReplayDeadOutboxMessages)
+	admin.Post("/outbox/:messageId/replay", middleware.Authorize(), handlers.ReplayOutboxMessage)
+	admin.Get("/webhooks", middleware.Authorize(), handlers.GetWebhooks)
+	admin.Post("/webhooks", middleware.Authorize(), handlers.CreateWebhook)
+	admin.Get("/webhooks/:webhookId", middleware.Authorize(), handlers.GetWebhook)
+	admin.Put("/webhooks/:webhookId", middleware.Authorize(), handlers.UpdateWebhook)
+	admin.Delete("/webhooks/:webhookId", middleware.Authorize(), handlers.DeleteWebhook)
+	admin.Get("/webhooks/:webhookId/deliveries", middleware.Authorize(), handlers.GetWebhookDeliveries)
+	admin.Post("/webhooks/:webhookId/ping", middleware.Authorize(), handlers.PingWebhook)
+	admin.Get("/jobs", middleware.Authorize(), handlers.GetJobs)
+	admin.Get("/jobs/:name", middleware.Authorize(), handlers.GetJob)
+	admin.Post("/jobs/:name/run", middleware.Authorize(), handlers.RunJob)
+	admin.Get("/feed", middleware.AuthorizeWebSocket(), handlers.AcceptFeed, websocket.New(handlers.Feed))
+}
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/notify"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Names of the built-in jobs.
+const (
+	RemindersJob = "conference_reminders"
+	CleanupJob   = "cleanup"
+)
+
+// reminderDays are the days before the start of a conference at which its customers are reminded.
+var reminderDays = []int{7, 1}
+
+// BuiltinJobs returns the reminders and the cleanup on their schedules of the configuration.
+func BuiltinJobs() []Job {
+	return []Job{
+		{Name: RemindersJob, Schedule: config.Current.Scheduler.ReminderSchedule, Run: SendReminders},
+		{Name: CleanupJob, Schedule: config.Current.Scheduler.CleanupSchedule, Run: Cleanup},
+	}
+}
+
+// remindersState remembers the sent reminders by booking, reminder day and conference start, so a
+// rescheduled conference is reminded again. Entries of started conferences are dropped.
+type remindersState struct {
+	Sent map[string]string `json:"sent"`
+}
+
+// SendReminders emails the customers of active bookings with an email address 7 days and 1 day before
+// their conference starts. A reminder is sent when its day is reached and the next one is not, bookings
+// made after that are not reminded of it. Nothing is sent while notifications are disabled.
+func SendReminders(run *Run) error {
+	sender := notify.CurrentSender()
+	if sender == nil {
+		return nil
+	}
+
+	state := remindersState{Sent: map[string]string{}}
+	if len(run.State) > 0 {
+		if err := json.Unmarshal(run.State, &state); err != nil {
+			return fmt.Errorf("cannot read reminders state: %v", err)
+		}
+	}
+	for key, startsAt := range state.Sent {
+		if start, err := time.Parse(time.RFC3339, startsAt); err != nil || !start.After(run.Now) {
+			delete(state.Sent, key)
+		}
+	}
+
+	conferences, err := database.ReadLocalDB()
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	for _, conference := range conferences {
+		start, err := time.Parse(time.RFC3339, conference.StartsAt)
+		if err != nil || conference.IsArchived || !start.After(run.Now) {
+			continue
+		}
+		days, windowStart, found := reminderDue(start, run.Now)
+		if !found {
+			continue
+		}
+
+		for _, booking := range conference.Bookings {
+			bookedAt, _ := time.Parse(time.RFC3339, booking.BookedAt)
+			key := fmt.Sprintf("%v/%v/%vd", conference.Id, booking.Id, days)
+			if booking.IsCanceled || booking.CustomerEmail == "" || bookedAt.After(windowStart) || state.Sent[key] == conference.StartsAt {
+				continue
+			}
+
+			data := notify.Data{Conference: conference, Booking: booking, DaysLeft: int(math.Ceil(start.Sub(run.Now).Hours() / 24))}
+			data.Conference.Bookings = nil
+			if err := notify.Deliver(sender, "reminder-"+key, notify.Reminder, data); err != nil {
+				logging.Default.Warn("cannot send reminder", "job", RemindersJob, "reminder", key, "error", err)
+				sendErr = fmt.Errorf("cannot send all reminders, last error: %v", err)
+				continue
+			}
+			state.Sent[key] = conference.StartsAt
+		}
+	}
+
+	stateJson, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	run.State = stateJson
+	return sendErr
+}
+
+// reminderDue returns the reminder day whose window contains now, windows end at the next reminder day.
+func reminderDue(start time.Time, now time.Time) (int, time.Time, bool) {
+	for dayIndex, days := range reminderDays {
+		windowStart := start.AddDate(0, 0, -days)
+		windowEnd := start
+		if dayIndex+1 < len(reminderDays) {
+			windowEnd = start.AddDate(0, 0, -reminderDays[dayIndex+1])
+		}
+		if !now.Before(windowStart) && now.Before(windowEnd) {
+			return days, windowStart, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+// Cleanup drops expired idempotency records. Expired holds and pending payments would be released
+// here as well, bookings are confirmed right away so far.
+func Cleanup(run *Run) error {
+	purged, err := database.PurgeExpiredIdempotencyKeys(run.Now)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		logging.Default.Info("dropped expired idempotency keys", "job", CleanupJob, "count", purged)
+	}
+	return nil
+}
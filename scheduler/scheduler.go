@@ -0,0 +1,354 @@
+// Package scheduler runs background jobs, e.g. the conference reminders. Jobs run on a cron schedule or
+// once at a given time, their state is stored in the job records. A run takes a lease on the record
+// before the job starts and the next run is only scheduled when it finished, so a run interrupted by
+// a crash is repeated: execution is at least once. With several replicas only the elected leader runs jobs.
+package scheduler
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/health"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Run is handed to a job. State is what the job stored in its last run, the job may replace it
+// to remember progress. It is stored after failed runs as well.
+type Run struct {
+	Now         time.Time
+	LastSuccess time.Time
+	State       json.RawMessage
+}
+
+// Job is a registered background job. Schedule is a cron expression, jobs without one run once at At.
+type Job struct {
+	Name     string
+	Schedule string
+	At       time.Time
+	Run      func(run *Run) error
+}
+
+// Elector tells whether this process may run the jobs.
+type Elector interface {
+	Leader() (bool, error)
+}
+
+// LocalElector is used without a shared database, the single process is always the leader.
+type LocalElector struct{}
+
+func (LocalElector) Leader() (bool, error) {
+	return true, nil
+}
+
+// MongoElector elects the leader through a lease in Mongo, the holder renews it on every poll
+// and other replicas take it over when it expires.
+type MongoElector struct {
+	Name   string
+	Holder string
+	TTL    time.Duration
+}
+
+func (e MongoElector) Leader() (bool, error) {
+	return database.AcquireLease(e.Name, e.Holder, e.TTL)
+}
+
+// Scheduler runs the due jobs on every poll.
+type Scheduler struct {
+	Jobs          []Job
+	Elector       Elector
+	Owner         string
+	PollInterval  time.Duration
+	LeaseDuration time.Duration
+	RetryBase     time.Duration
+	MaxBackoff    time.Duration
+	Now           func() time.Time
+
+	crons      map[string]Cron
+	registered bool
+	leader     bool
+}
+
+var (
+	errNotDue    = errors.New("job is not due")
+	errLeaseLost = errors.New("the lease of the job was taken over during the run")
+)
+
+// NewScheduler returns a scheduler with the scheduler settings of the configuration, see config.Scheduler.
+// It fails for jobs with an invalid schedule.
+func NewScheduler(elector Elector, jobs ...Job) (*Scheduler, error) {
+	settings := config.Current.Scheduler
+	scheduler := &Scheduler{
+		Jobs:          jobs,
+		Elector:       elector,
+		Owner:         processName(),
+		PollInterval:  settings.PollInterval,
+		LeaseDuration: settings.LeaseDuration,
+		RetryBase:     settings.RetryBase,
+		MaxBackoff:    settings.MaxBackoff,
+		Now:           time.Now,
+		crons:         map[string]Cron{},
+	}
+
+	for _, job := range jobs {
+		if job.Schedule == "" {
+			continue
+		}
+		cron, err := ParseCron(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %v: %v", job.Name, err)
+		}
+		scheduler.crons[job.Name] = cron
+	}
+	return scheduler, nil
+}
+
+// NewElector returns the elector of the named lease, through Mongo when the leases collection is
+// available and LocalElector otherwise. The lease expires after the leader TTL of the configuration.
+func NewElector(name string) Elector {
+	if database.LeasesCollection == nil {
+		return LocalElector{}
+	}
+	return MongoElector{Name: name, Holder: processName(), TTL: config.Current.Scheduler.LeaderTTL}
+}
+
+// Start runs a scheduler with the built-in jobs in the background until stop is closed. The leader is
+// elected through Mongo when the leases collection is available. The returned channel is closed when
+// the running jobs finished.
+func Start(stop <-chan struct{}) (<-chan struct{}, error) {
+	scheduler, err := NewScheduler(NewElector("scheduler"), BuiltinJobs()...)
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(stop)
+	}()
+	return done, nil
+}
+
+// Heartbeat is beaten after every poll of Run, readiness reports the scheduler down when it stops.
+var Heartbeat = health.NewHeartbeat()
+
+// Run polls until stop is closed. Errors are logged and retried with the next poll.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	defer Heartbeat.Stop()
+	for {
+		err := s.Poll()
+		if err != nil {
+			logging.Default.Error("scheduler poll failed", "error", err)
+		}
+		// a poll runs the due jobs, the worker is only stuck when a poll takes longer than a lease
+		Heartbeat.Beat(3*s.PollInterval+s.LeaseDuration, err)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll runs the due jobs one after another if this process is the leader.
+func (s *Scheduler) Poll() error {
+	leader, err := s.Elector.Leader()
+	if err != nil {
+		return fmt.Errorf("cannot elect leader: %v", err)
+	}
+	if leader != s.leader {
+		logging.Default.Info("scheduler leadership changed", "owner", s.Owner, "leader", leader)
+		s.leader = leader
+	}
+	if !leader {
+		return nil
+	}
+
+	if !s.registered {
+		if err := s.register(); err != nil {
+			return fmt.Errorf("cannot register jobs: %v", err)
+		}
+		s.registered = true
+	}
+
+	for _, job := range s.Jobs {
+		if err := s.runIfDue(job); err != nil {
+			logging.Default.Error("job failed", "job", job.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// register creates the records of new jobs and reschedules the jobs whose schedule changed.
+func (s *Scheduler) register() error {
+	now := s.Now()
+	for _, job := range s.Jobs {
+		_, err := database.UpdateJob(job.Name, true, func(record *model.JobRecord) error {
+			if cron, recurring := s.crons[job.Name]; recurring {
+				if record.Schedule != job.Schedule || record.NextRunAt == "" {
+					record.NextRunAt = formatTime(cron.Next(now))
+				}
+			} else if record.Status != model.JobDone && (record.Schedule != "" || record.NextRunAt == "") {
+				record.NextRunAt = formatTime(job.At)
+			}
+			record.Schedule = job.Schedule
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runIfDue claims the job record if the job is due and runs it. A record which is running under an
+// expired lease belongs to a crashed run and is claimed again. The lease is renewed while the job runs,
+// and the outcome is only stored if the run still holds it.
+func (s *Scheduler) runIfDue(job Job) error {
+	startedAt := s.Now()
+	run := &Run{Now: startedAt}
+	_, err := database.UpdateJob(job.Name, false, func(record *model.JobRecord) error {
+		if record.Status == model.JobDone || record.NextRunAt == "" {
+			return errNotDue
+		}
+		if leaseExpiresAt, err := time.Parse(time.RFC3339, record.LeaseExpiresAt); record.Status == model.JobRunning && err == nil && leaseExpiresAt.After(startedAt) {
+			return errNotDue
+		}
+		if nextRunAt, err := time.Parse(time.RFC3339, record.NextRunAt); err != nil || nextRunAt.After(startedAt) {
+			return errNotDue
+		}
+
+		record.Status = model.JobRunning
+		record.LeaseOwner = s.Owner
+		record.LeaseExpiresAt = formatTime(startedAt.Add(s.LeaseDuration))
+		record.LastStartedAt = formatTime(startedAt)
+		record.Runs++
+		run.LastSuccess, _ = time.Parse(time.RFC3339, record.LastSuccessAt)
+		run.State = record.State
+		return nil
+	})
+	if errors.Is(err, errNotDue) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stopRenewing := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLease(job.Name, startedAt, stopRenewing)
+	}()
+	runErr := runJob(job, run)
+	close(stopRenewing)
+	<-renewed
+
+	finishedAt := s.Now()
+	_, err = database.UpdateJob(job.Name, false, func(record *model.JobRecord) error {
+		if !s.holdsLease(record, startedAt) {
+			return errLeaseLost
+		}
+		record.LeaseOwner = ""
+		record.LeaseExpiresAt = ""
+		record.LastFinishedAt = formatTime(finishedAt)
+		record.State = run.State
+		cron, recurring := s.crons[job.Name]
+
+		if runErr != nil {
+			record.Status = model.JobScheduled
+			record.Failures++
+			record.LastError = runErr.Error()
+			retryAt := finishedAt.Add(s.backoff(record.Failures))
+			if recurring && cron.Next(finishedAt).Before(retryAt) {
+				retryAt = cron.Next(finishedAt)
+			}
+			record.NextRunAt = formatTime(retryAt)
+			return nil
+		}
+
+		record.Failures = 0
+		record.LastError = ""
+		record.LastSuccessAt = formatTime(startedAt)
+		if recurring {
+			record.Status = model.JobScheduled
+			record.NextRunAt = formatTime(cron.Next(finishedAt))
+		} else {
+			record.Status = model.JobDone
+			record.NextRunAt = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return runErr
+}
+
+// renewLease extends the lease of the run started at startedAt every third of the lease duration
+// until stop is closed.
+func (s *Scheduler) renewLease(name string, startedAt time.Time, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.LeaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		_, err := database.UpdateJob(name, false, func(record *model.JobRecord) error {
+			if !s.holdsLease(record, startedAt) {
+				return errLeaseLost
+			}
+			record.LeaseExpiresAt = formatTime(s.Now().Add(s.LeaseDuration))
+			return nil
+		})
+		if err != nil {
+			logging.Default.Warn("cannot renew the job lease", "job", name, "error", err)
+		}
+	}
+}
+
+// holdsLease tells whether the record is still leased by the run of this scheduler started at startedAt.
+func (s *Scheduler) holdsLease(record *model.JobRecord, startedAt time.Time) bool {
+	return record.Status == model.JobRunning && record.LeaseOwner == s.Owner && record.LastStartedAt == formatTime(startedAt)
+}
+
+// runJob turns a panic of the job into a failed run.
+func runJob(job Job, run *Run) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return job.Run(run)
+}
+
+// backoff doubles the retry delay with every failure up to MaxBackoff.
+func (s *Scheduler) backoff(failures int) time.Duration {
+	delay := s.RetryBase
+	for attempt := 1; attempt < failures && delay < s.MaxBackoff; attempt++ {
+		delay *= 2
+	}
+	if delay > s.MaxBackoff {
+		delay = s.MaxBackoff
+	}
+	return delay
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// processName identifies the replica in leases and job records.
+func processName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%v-%v", hostname, os.Getpid())
+}
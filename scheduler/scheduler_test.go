@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"booking-webapp/notify"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSender struct {
+	messages []notify.Message
+}
+
+func (s *recordingSender) Send(message notify.Message) error {
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+type follower struct{}
+
+func (follower) Leader() (bool, error) {
+	return false, nil
+}
+
+func useTestDB(t *testing.T) {
+	dir := t.TempDir()
+	for path, name := range map[*string]string{
+		&config.LOCAL_DB_PATH: "conferences.json",
+		&config.JOBS_DB_PATH:  "jobs.json",
+	} {
+		path, prevPath := path, *path
+		*path = filepath.Join(dir, name)
+		t.Cleanup(func() { *path = prevPath })
+	}
+}
+
+func at(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339, value)
+	return parsed
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expression string
+		after      string
+		next       string
+	}{
+		{"*/15 * * * *", "2023-03-01T10:07:30Z", "2023-03-01T10:15:00Z"},
+		{"@daily", "2023-03-01T10:07:00Z", "2023-03-02T00:00:00Z"},
+		{"30 9 * * 1-5", "2023-03-03T10:00:00Z", "2023-03-06T09:30:00Z"},
+		{"0 0 29 2 *", "2023-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		// day of month or day of week, like cron
+		{"0 12 13 * 5", "2023-01-01T00:00:00Z", "2023-01-06T12:00:00Z"},
+		{"0 0 * * 7", "2023-03-01T00:00:00Z", "2023-03-05T00:00:00Z"},
+	}
+	for _, test := range tests {
+		cron, err := ParseCron(test.expression)
+		assert.NoError(t, err, test.expression)
+		assert.Equal(t, at(test.next), cron.Next(at(test.after)), test.expression)
+	}
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSchedulerRunsDueJobsAtLeastOnce(t *testing.T) {
+	useTestDB(t)
+	now := at("2023-03-01T10:07:00Z")
+	failures := 1
+	runs := []time.Time{}
+	oneShotRuns := 0
+
+	scheduler, err := NewScheduler(LocalElector{},
+		Job{Name: "recurring", Schedule: "*/15 * * * *", Run: func(run *Run) error {
+			runs = append(runs, run.Now)
+			if failures > 0 {
+				failures--
+				return errors.New("database is down")
+			}
+			run.State = []byte(`{"runs":1}`)
+			return nil
+		}},
+		Job{Name: "once", At: at("2023-03-01T10:20:00Z"), Run: func(run *Run) error {
+			oneShotRuns++
+			return nil
+		}},
+	)
+	assert.NoError(t, err)
+	scheduler.Now = func() time.Time { return now }
+
+	// nothing is due at registration
+	assert.NoError(t, scheduler.Poll())
+	assert.Empty(t, runs)
+	job, err := database.GetJob("recurring")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-03-01T10:15:00Z", job.NextRunAt)
+
+	// a failed run is retried after the backoff, which is shorter than the schedule
+	now = at("2023-03-01T10:15:00Z")
+	assert.NoError(t, scheduler.Poll())
+	job, _ = database.GetJob("recurring")
+	assert.Equal(t, 1, job.Failures)
+	assert.Equal(t, "database is down", job.LastError)
+	assert.Equal(t, "2023-03-01T10:16:00Z", job.NextRunAt)
+
+	now = at("2023-03-01T10:20:00Z")
+	assert.NoError(t, scheduler.Poll())
+	job, _ = database.GetJob("recurring")
+	assert.Equal(t, model.JobScheduled, job.Status)
+	assert.Equal(t, 0, job.Failures)
+	assert.Equal(t, 2, job.Runs)
+	assert.Equal(t, "2023-03-01T10:20:00Z", job.LastSuccessAt)
+	assert.Equal(t, "2023-03-01T10:30:00Z", job.NextRunAt)
+	assert.JSONEq(t, `{"runs":1}`, string(job.State))
+
+	once, _ := database.GetJob("once")
+	assert.Equal(t, model.JobDone, once.Status)
+	assert.Equal(t, 1, oneShotRuns)
+
+	// a run which crashed keeps its record running, it is repeated when the lease expired
+	_, err = database.UpdateJob("recurring", false, func(job *model.JobRecord) error {
+		job.Status = model.JobRunning
+		job.LeaseOwner = "crashed-replica"
+		job.LeaseExpiresAt = "2023-03-01T10:40:00Z"
+		return nil
+	})
+	assert.NoError(t, err)
+	now = at("2023-03-01T10:35:00Z")
+	assert.NoError(t, scheduler.Poll())
+	assert.Len(t, runs, 2)
+	now = at("2023-03-01T10:41:00Z")
+	assert.NoError(t, scheduler.Poll())
+	assert.Len(t, runs, 3)
+	assert.Equal(t, 1, oneShotRuns)
+
+	// only the leader runs jobs
+	now = at("2023-03-01T12:00:00Z")
+	scheduler.Elector = follower{}
+	assert.NoError(t, scheduler.Poll())
+	assert.Len(t, runs, 3)
+}
+
+func TestSchedulerKeepsTheLeaseOfLongRuns(t *testing.T) {
+	useTestDB(t)
+	var mutex sync.Mutex
+	now := at("2023-03-01T10:07:00Z")
+	setNow := func(value time.Time) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		now = value
+	}
+
+	takeOver := false
+	scheduler, err := NewScheduler(LocalElector{}, Job{Name: "long", Schedule: "*/15 * * * *", Run: func(run *Run) error {
+		// the lease is renewed while the job runs
+		setNow(at("2023-03-01T11:00:00Z"))
+		assert.Eventually(t, func() bool {
+			job, _ := database.GetJob("long")
+			return job.LeaseExpiresAt == "2023-03-01T11:00:00Z"
+		}, time.Second, time.Millisecond)
+
+		if takeOver {
+			_, err := database.UpdateJob("long", false, func(job *model.JobRecord) error {
+				job.LeaseOwner = "other-replica"
+				job.LastStartedAt = "2023-03-01T11:00:00Z"
+				return nil
+			})
+			assert.NoError(t, err)
+		}
+		return errors.New("database is down")
+	}})
+	assert.NoError(t, err)
+	scheduler.LeaseDuration = 30 * time.Millisecond
+	scheduler.Now = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+	assert.NoError(t, scheduler.Poll())
+
+	setNow(at("2023-03-01T10:15:00Z"))
+	assert.NoError(t, scheduler.Poll())
+	job, _ := database.GetJob("long")
+	assert.Equal(t, model.JobScheduled, job.Status)
+	assert.Equal(t, 1, job.Failures)
+
+	// a run which lost its lease leaves the record to the new owner
+	takeOver = true
+	setNow(at("2023-03-01T11:30:00Z"))
+	assert.NoError(t, scheduler.Poll())
+	job, _ = database.GetJob("long")
+	assert.Equal(t, model.JobRunning, job.Status)
+	assert.Equal(t, "other-replica", job.LeaseOwner)
+	assert.Equal(t, 1, job.Failures)
+	assert.Equal(t, "2023-03-01T11:01:00Z", job.NextRunAt)
+}
+
+func TestSendReminders(t *testing.T) {
+	useTestDB(t)
+	sender := &recordingSender{}
+	notify.SetSender(sender)
+	t.Cleanup(func() { notify.SetSender(nil) })
+
+	assert.NoError(t, database.CommitConferencesToLocalDB([]model.Conference{{
+		Id: "conf1", ConferenceName: "Boston 2023", TotalTickets: 100, RemainingTickets: 94, StartsAt: "2023-03-10T09:00:00Z",
+		Bookings: []model.Booking{
+			{Id: "booking1", CustomerName: "Jane Doe", CustomerEmail: "jane@example.com", TicketsBooked: 2, BookedAt: "2023-01-10T10:00:00Z"},
+			{Id: "booking2", CustomerName: "John Doe", TicketsBooked: 1, BookedAt: "2023-01-11T10:00:00Z"},
+			{Id: "booking3", CustomerName: "Adam Smith", CustomerEmail: "adam@example.com", TicketsBooked: 3, BookedAt: "2023-03-05T10:00:00Z"},
+			{Id: "booking4", CustomerName: "Bob Canceled", CustomerEmail: "bob@example.com", TicketsBooked: 1, BookedAt: "2023-01-12T10:00:00Z", IsCanceled: true},
+		},
+	}}))
+
+	run := &Run{Now: at("2023-03-01T09:00:00Z")}
+	assert.NoError(t, SendReminders(run))
+	assert.Empty(t, sender.messages)
+
+	// 7 days before, only bookings made before that day are reminded, and only once
+	run.Now = at("2023-03-05T12:00:00Z")
+	assert.NoError(t, SendReminders(run))
+	run.Now = at("2023-03-05T12:15:00Z")
+	assert.NoError(t, SendReminders(run))
+	if assert.Len(t, sender.messages, 1) {
+		assert.Equal(t, "jane@example.com", sender.messages[0].To)
+		assert.Equal(t, "reminder-conf1/booking1/7d", sender.messages[0].Id)
+		assert.Equal(t, "Boston 2023 starts in 5 days", sender.messages[0].Subject)
+	}
+
+	run.Now = at("2023-03-09T09:00:00Z")
+	assert.NoError(t, SendReminders(run))
+	if assert.Len(t, sender.messages, 3) {
+		assert.Equal(t, "Boston 2023 starts tomorrow", sender.messages[1].Subject)
+		assert.ElementsMatch(t, []string{"reminder-conf1/booking1/1d", "reminder-conf1/booking3/1d"}, []string{sender.messages[1].Id, sender.messages[2].Id})
+	}
+
+	// the started conference is forgotten
+	run.Now = at("2023-03-10T09:00:00Z")
+	assert.NoError(t, SendReminders(run))
+	assert.JSONEq(t, `{"sent":{}}`, string(run.State))
+}
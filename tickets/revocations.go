@@ -0,0 +1,72 @@
+package tickets
+
+import (
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+)
+
+// Reasons of revocation list entries.
+const (
+	RevokedBookingCanceled   = "booking_canceled"
+	RevokedTicketsChanged    = "tickets_changed"
+	RevokedConferenceDeleted = "conference_deleted"
+)
+
+// Revocation is an entry of the revocation list. Scanners reject every ticket of canceled bookings
+// and deleted conferences, and for changed bookings the ticket numbers above ValidTickets
+// of the latest entry of the booking. TicketVersions of that entry holds the version of the valid
+// tickets when numbers were issued again, codes of ticket n with a lower version than
+// TicketVersions[n-1] are rejected as well.
+type Revocation struct {
+	Seq            uint64 `json:"seq"`
+	Reason         string `json:"reason"`
+	ConferenceId   string `json:"conference_id"`
+	BookingId      string `json:"booking_id,omitempty"`
+	ValidTickets   *uint  `json:"valid_tickets,omitempty"`
+	TicketVersions []uint `json:"ticket_versions,omitempty"`
+	RevokedAt      string `json:"revoked_at"`
+}
+
+// Revocations lists the ledger events after the since cursor which make issued tickets invalid.
+// Scanners keep the seq of the last entry as cursor for the next sync.
+func Revocations(events []model.Event, since uint64) []Revocation {
+	revocations := []Revocation{}
+	// the tickets of the bookings are followed from the start, their versions depend on all changes
+	bookings := map[string]model.Booking{}
+	for _, event := range events {
+		bookingKey := event.ConferenceId + "/" + event.BookingId
+		booking := bookings[bookingKey]
+		switch event.Type {
+		case model.EventBookingCreated:
+			bookings[bookingKey] = model.Booking{TicketsBooked: event.TicketsBooked}
+		case model.EventTicketsChanged:
+			booking.TicketVersions = ledger.ChangeTicketVersions(booking.TicketVersions, booking.TicketsBooked, event.TicketsBooked)
+			booking.TicketsBooked = event.TicketsBooked
+			bookings[bookingKey] = booking
+		}
+		if event.Seq <= since {
+			continue
+		}
+
+		revocation := Revocation{Seq: event.Seq, ConferenceId: event.ConferenceId, BookingId: event.BookingId, RevokedAt: event.OccurredAt}
+		switch event.Type {
+		case model.EventBookingCanceled:
+			revocation.Reason = RevokedBookingCanceled
+		case model.EventTicketsChanged:
+			validTickets := event.TicketsBooked
+			revocation.Reason = RevokedTicketsChanged
+			revocation.ValidTickets = &validTickets
+			if len(booking.TicketVersions) > 0 {
+				for ticket := uint(1); ticket <= validTickets; ticket++ {
+					revocation.TicketVersions = append(revocation.TicketVersions, ledger.TicketVersion(booking, ticket))
+				}
+			}
+		case model.EventConferenceDeleted:
+			revocation.Reason = RevokedConferenceDeleted
+		default:
+			continue
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations
+}
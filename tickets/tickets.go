@@ -0,0 +1,136 @@
+// Package tickets issues and verifies the codes of single tickets. A booking of n tickets has the
+// tickets 1 to n. The code of a ticket is an Ed25519 signed token, so door scanners can verify it
+// offline with the public key and the revocation list.
+package tickets
+
+import (
+	"booking-webapp/config"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCode is returned for codes which are malformed or not signed by this service.
+	ErrInvalidCode = errors.New("invalid ticket code")
+	// ErrOutsideValidity is returned for correctly signed codes used before or after their validity window.
+	ErrOutsideValidity = errors.New("ticket code is not valid at this time")
+)
+
+// Ticket is the payload of a ticket code. The ticket is valid from NotBefore until ExpiresAt, unix seconds.
+// Version tells apart the codes of a ticket number which was revoked and issued again, see ledger.TicketVersion.
+type Ticket struct {
+	ConferenceId string `json:"c"`
+	BookingId    string `json:"b"`
+	Number       uint   `json:"n"`
+	Version      uint   `json:"v,omitempty"`
+	NotBefore    int64  `json:"nbf"`
+	ExpiresAt    int64  `json:"exp"`
+	KeyId        string `json:"kid"`
+}
+
+// Issue returns the code of the ticket, the base64url encoded payload and signature joined by a dot.
+// The ticket is valid from the booking time for TICKET_VALIDITY (a year by default), so the code
+// of a ticket does not change between requests until the ticket number is issued again.
+func Issue(confId string, bookingId string, number uint, version uint, bookedAt string) (string, error) {
+	privateKey, keyId, err := signingKey()
+	if err != nil {
+		return "", err
+	}
+	validity := config.Current.Tickets.Validity
+
+	validFrom, err := time.Parse(time.RFC3339, bookedAt)
+	if err != nil {
+		return "", fmt.Errorf("cannot issue tickets of booking %v booked at %q", bookingId, bookedAt)
+	}
+	payload, err := json.Marshal(Ticket{
+		ConferenceId: confId,
+		BookingId:    bookingId,
+		Number:       number,
+		Version:      version,
+		NotBefore:    validFrom.Unix(),
+		ExpiresAt:    validFrom.Add(validity).Unix(),
+		KeyId:        keyId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(privateKey, payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Parse verifies the signature and validity window of the code and returns its ticket.
+func Parse(code string, now time.Time) (Ticket, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(strings.TrimSpace(code), ".")
+	if !found {
+		return Ticket{}, ErrInvalidCode
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Ticket{}, ErrInvalidCode
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return Ticket{}, ErrInvalidCode
+	}
+
+	publicKey, _, err := PublicKey()
+	if err != nil {
+		return Ticket{}, err
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return Ticket{}, ErrInvalidCode
+	}
+
+	ticket := Ticket{}
+	if err := json.Unmarshal(payload, &ticket); err != nil || ticket.BookingId == "" || ticket.Number == 0 {
+		return Ticket{}, ErrInvalidCode
+	}
+	if now.Unix() < ticket.NotBefore || now.Unix() >= ticket.ExpiresAt {
+		return ticket, ErrOutsideValidity
+	}
+	return ticket, nil
+}
+
+// PublicKey returns the key to verify ticket codes and its id, the id is part of every code.
+func PublicKey() (ed25519.PublicKey, string, error) {
+	privateKey, keyId, err := signingKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return privateKey.Public().(ed25519.PublicKey), keyId, nil
+}
+
+// signingKey reads the base64 encoded 32 byte seed from TICKET_PRIVATE_KEY, or derives it from
+// the JWT secret SIGN when it is not set, so ticket codes and tokens never share a key.
+// The key id is the start of the SHA-256 hash of the public key.
+func signingKey() (ed25519.PrivateKey, string, error) {
+	var seed []byte
+	if encodedSeed := config.GetEnvOrDefault("TICKET_PRIVATE_KEY", ""); encodedSeed != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encodedSeed)
+		if err != nil || len(decoded) != ed25519.SeedSize {
+			return nil, "", fmt.Errorf("TICKET_PRIVATE_KEY has to be a base64 encoded %v byte Ed25519 seed", ed25519.SeedSize)
+		}
+		seed = decoded
+	} else {
+		sign, err := config.GetSecret("SIGN")
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot sign tickets: %v", err)
+		}
+		mac := hmac.New(sha256.New, []byte(sign))
+		mac.Write([]byte("ticket codes"))
+		seed = mac.Sum(nil)
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	keyHash := sha256.Sum256(privateKey.Public().(ed25519.PublicKey))
+	return privateKey, hex.EncodeToString(keyHash[:8]), nil
+}
@@ -0,0 +1,69 @@
+package tickets
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/model"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeVerifiesOffline(t *testing.T) {
+	t.Setenv("SIGN", "test-sign")
+	prevValidity := config.Current.Tickets.Validity
+	config.Current.Tickets.Validity = 720 * time.Hour
+	t.Cleanup(func() { config.Current.Tickets.Validity = prevValidity })
+	bookedAt := time.Date(2022, 11, 2, 16, 29, 20, 0, time.UTC)
+
+	code, err := Issue("conf1", "booking1", 3, 0, bookedAt.Format(time.RFC3339))
+	assert.NoError(t, err)
+	again, _ := Issue("conf1", "booking1", 3, 0, bookedAt.Format(time.RFC3339))
+	assert.Equal(t, code, again, "codes are stable")
+	reissued, _ := Issue("conf1", "booking1", 3, 1, bookedAt.Format(time.RFC3339))
+	assert.NotEqual(t, code, reissued, "a ticket issued again gets a new code")
+
+	ticket, err := Parse(code, bookedAt.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), ticket.Number)
+	_, err = Parse(code, bookedAt.Add(721*time.Hour))
+	assert.ErrorIs(t, err, ErrOutsideValidity)
+
+	publicKey, keyId, err := PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, keyId, ticket.KeyId)
+	payload, signature, _ := strings.Cut(code, ".")
+	decodedPayload, _ := base64.RawURLEncoding.DecodeString(payload)
+	decodedSignature, _ := base64.RawURLEncoding.DecodeString(signature)
+	assert.True(t, ed25519.Verify(publicKey, decodedPayload, decodedSignature), "the public key alone verifies the code")
+
+	forged, _ := Issue("conf1", "booking1", 4, 0, bookedAt.Format(time.RFC3339))
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	_, err = Parse(forgedPayload+"."+signature, bookedAt.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	t.Setenv("TICKET_PRIVATE_KEY", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	_, err = Parse(code, bookedAt.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestRevocations(t *testing.T) {
+	revocations := Revocations([]model.Event{
+		{Seq: 1, Type: model.EventBookingCreated, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 3},
+		{Seq: 2, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 2},
+		{Seq: 3, Type: model.EventTicketsChanged, ConferenceId: "conf1", BookingId: "b1", TicketsBooked: 4},
+		{Seq: 4, Type: model.EventBookingCanceled, ConferenceId: "conf1", BookingId: "b1"},
+		{Seq: 5, Type: model.EventConferenceDeleted, ConferenceId: "conf2"},
+	}, 1)
+
+	if assert.Len(t, revocations, 4) {
+		assert.Equal(t, uint(2), *revocations[0].ValidTickets)
+		assert.Equal(t, uint(4), *revocations[1].ValidTickets)
+		assert.Equal(t, []uint{0, 0, 1, 0}, revocations[1].TicketVersions, "ticket 3 was revoked and issued again")
+		assert.Equal(t, RevokedBookingCanceled, revocations[2].Reason)
+		assert.Equal(t, RevokedConferenceDeleted, revocations[3].Reason)
+	}
+}
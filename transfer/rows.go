@@ -0,0 +1,259 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV        = "csv"
+	FormatJSONLines  = "jsonl"
+	maxJSONLineBytes = 1024 * 1024
+)
+
+// Columns are the CSV header and JSON Lines keys, in export order. check_ins lists the checked-in
+// ticket numbers and ticket_versions the code versions of the tickets, both are only exported,
+// see Import.
+var Columns = []string{
+	"conference_id", "conference_name", "total_tickets", "starts_at", "is_archived",
+	"booking_id", "customer_name", "customer_email", "tickets_booked", "booked_at", "updated_at", "is_canceled",
+	"check_ins", "ticket_versions",
+}
+
+// Row is a booking together with its conference. A conference without bookings
+// is exported as a single row with empty booking fields.
+type Row struct {
+	Line           int     `json:"-"`
+	ConferenceId   string  `json:"conference_id"`
+	ConferenceName string  `json:"conference_name"`
+	TotalTickets   *uint   `json:"total_tickets"`
+	StartsAt       string  `json:"starts_at,omitempty"`
+	IsArchived     *bool   `json:"is_archived,omitempty"`
+	BookingId      string  `json:"booking_id,omitempty"`
+	CustomerName   *string `json:"customer_name,omitempty"`
+	CustomerEmail  *string `json:"customer_email,omitempty"`
+	TicketsBooked  *uint   `json:"tickets_booked,omitempty"`
+	BookedAt       string  `json:"booked_at,omitempty"`
+	UpdatedAt      string  `json:"updated_at,omitempty"`
+	IsCanceled     bool    `json:"is_canceled,omitempty"`
+	CheckIns       []uint  `json:"check_ins,omitempty"`
+	TicketVersions []uint  `json:"ticket_versions,omitempty"`
+}
+
+// RowError is a problem of a single input row, Line is the line number in the input.
+type RowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (row Row) hasBooking() bool {
+	return row.BookingId != "" || row.CustomerName != nil || row.CustomerEmail != nil || row.TicketsBooked != nil
+}
+
+// IsFormat reports whether format is one of the supported row formats.
+func IsFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSONLines
+}
+
+// readRows parses the input, rows which cannot be parsed are reported as errors and left out.
+func readRows(r io.Reader, format string) ([]Row, []RowError, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSONLines:
+		return readJSONLines(r)
+	}
+	return nil, nil, fmt.Errorf("unsupported format %v, use %v or %v", format, FormatCSV, FormatJSONLines)
+}
+
+func readCSV(r io.Reader) ([]Row, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Row{}, []RowError{}, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("cannot read CSV header: %v", err)
+	}
+	for index, column := range header {
+		header[index] = strings.TrimSpace(column)
+		if !isColumn(header[index]) {
+			return nil, nil, fmt.Errorf("unknown CSV column %q, supported columns are %v", column, strings.Join(Columns, ", "))
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+
+	rows := []Row{}
+	rowErrs := []RowError{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			rowErrs = append(rowErrs, RowError{Line: parseErr.Line, Field: "", Message: parseErr.Err.Error()})
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{Line: line}
+		isParsed := true
+		for index, value := range record {
+			if err := row.set(header[index], strings.TrimSpace(value)); err != nil {
+				rowErrs = append(rowErrs, RowError{Line: line, Field: header[index], Message: err.Error()})
+				isParsed = false
+			}
+		}
+		if isParsed {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, rowErrs, nil
+}
+
+func readJSONLines(r io.Reader) ([]Row, []RowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLineBytes)
+
+	rows := []Row{}
+	rowErrs := []RowError{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := Row{}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			rowErrs = append(rowErrs, RowError{Line: line, Field: "", Message: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrs, scanner.Err()
+}
+
+// set assigns the CSV value of the column, empty values leave the field unset.
+func (row *Row) set(column string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	switch column {
+	case "conference_id":
+		row.ConferenceId = value
+	case "conference_name":
+		row.ConferenceName = value
+	case "total_tickets", "tickets_booked":
+		number, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole positive number", value)
+		}
+		count := uint(number)
+		if column == "total_tickets" {
+			row.TotalTickets = &count
+		} else {
+			row.TicketsBooked = &count
+		}
+	case "starts_at":
+		row.StartsAt = value
+	case "is_archived":
+		archived, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		row.IsArchived = &archived
+	case "check_ins", "ticket_versions":
+		numbers := []uint{}
+		for _, field := range strings.Fields(value) {
+			number, err := strconv.ParseUint(field, 10, 0)
+			if err != nil {
+				return fmt.Errorf("%q is not a list of whole positive numbers separated by spaces", value)
+			}
+			numbers = append(numbers, uint(number))
+		}
+		if column == "check_ins" {
+			row.CheckIns = numbers
+		} else {
+			row.TicketVersions = numbers
+		}
+	case "booking_id":
+		row.BookingId = value
+	case "customer_name":
+		row.CustomerName = &value
+	case "customer_email":
+		row.CustomerEmail = &value
+	case "booked_at":
+		row.BookedAt = value
+	case "updated_at":
+		row.UpdatedAt = value
+	case "is_canceled":
+		canceled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		row.IsCanceled = canceled
+	}
+	return nil
+}
+
+// record returns the CSV values of the row in the order of Columns.
+func (row Row) record() []string {
+	optionalUint := func(value *uint) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*value), 10)
+	}
+	optionalString := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+	numbers := func(values []uint) string {
+		formatted := make([]string, len(values))
+		for index, value := range values {
+			formatted[index] = strconv.FormatUint(uint64(value), 10)
+		}
+		return strings.Join(formatted, " ")
+	}
+	canceled := ""
+	if row.hasBooking() {
+		canceled = strconv.FormatBool(row.IsCanceled)
+	}
+
+	return []string{
+		row.ConferenceId, row.ConferenceName, optionalUint(row.TotalTickets), row.StartsAt, strconv.FormatBool(row.isArchived()),
+		row.BookingId, optionalString(row.CustomerName), optionalString(row.CustomerEmail), optionalUint(row.TicketsBooked),
+		row.BookedAt, row.UpdatedAt, canceled, numbers(row.CheckIns), numbers(row.TicketVersions),
+	}
+}
+
+func (row Row) isArchived() bool {
+	return row.IsArchived != nil && *row.IsArchived
+}
+
+func isColumn(column string) bool {
+	for _, known := range Columns {
+		if column == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package transfer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRows(t *testing.T) {
+	rows, rowErrs, err := readRows(strings.NewReader("conference_name, tickets_booked,customer_name\n"+
+		"\"Boston, 2023\",2,Roman Bauer\n"+
+		"Oslo,-1,Jane Doe\n"), FormatCSV)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, "Boston, 2023", rows[0].ConferenceName)
+		assert.Equal(t, uint(2), *rows[0].TicketsBooked)
+		assert.Nil(t, rows[0].TotalTickets)
+	}
+	assert.Equal(t, []RowError{{Line: 3, Field: "tickets_booked", Message: `"-1" is not a whole positive number`}}, rowErrs)
+
+	_, _, err = readRows(strings.NewReader("conference,total_tickets\n"), FormatCSV)
+	assert.Error(t, err)
+
+	rows, rowErrs, err = readRows(strings.NewReader("conference_id,is_archived,check_ins\nconf1,true,1 3\nconf1,,1;3\n"), FormatCSV)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.True(t, *rows[0].IsArchived)
+		assert.Equal(t, []uint{1, 3}, rows[0].CheckIns)
+	}
+	assert.Equal(t, []RowError{{Line: 3, Field: "check_ins", Message: `"1;3" is not a list of whole positive numbers separated by spaces`}}, rowErrs)
+
+	rows, rowErrs, err = readRows(strings.NewReader("{\"conference_id\": \"conf1\"}\n\n{\"conference\": \"Oslo\"}\n"), FormatJSONLines)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	if assert.Len(t, rowErrs, 1) {
+		assert.Equal(t, 3, rowErrs[0].Line)
+	}
+}
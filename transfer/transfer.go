@@ -0,0 +1,465 @@
+// Package transfer imports and exports conferences with their bookings as CSV or JSON Lines rows,
+// one row per booking. Imported rows go through the same validators and ledger as API requests.
+package transfer
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/ledger"
+	"booking-webapp/model"
+	"booking-webapp/validation"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Options control an import, RequestId is recorded in the created events.
+type Options struct {
+	DryRun    bool
+	RequestId string
+}
+
+// Report is the result of an import. Nothing is imported when it has errors.
+type Report struct {
+	DryRun      bool                 `json:"dry_run"`
+	Rows        int                  `json:"rows"`
+	Errors      []RowError           `json:"errors"`
+	Conferences []ImportedConference `json:"conferences"`
+}
+
+// ImportedConference lists what an import adds to a conference. Before is nil for created
+// conferences, After is the stored state once the import was applied.
+type ImportedConference struct {
+	Id             string            `json:"id"`
+	ConferenceName string            `json:"conference_name"`
+	Created        bool              `json:"created"`
+	Bookings       []string          `json:"bookings"`
+	Before         *model.Conference `json:"-"`
+	After          model.Conference  `json:"-"`
+
+	events  []model.Event
+	failed  bool
+	archive bool
+}
+
+// ApplyError is returned by Import when the rows were valid but could not be applied, e.g. because the
+// conferences were changed meanwhile. Nothing was imported then.
+type ApplyError struct {
+	Err error
+}
+
+func (e ApplyError) Error() string {
+	return fmt.Sprintf("nothing was imported: %v", e.Err)
+}
+
+func (e ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// IsImported reports whether the rows were valid and applied.
+func (report Report) IsImported() bool {
+	return !report.DryRun && len(report.Errors) == 0
+}
+
+// Import validates every row against the stored conferences and the rows before it,
+// and applies them through the ledger unless there are errors or it is a dry run.
+// All conferences are applied at once, so an import is either complete or nothing is imported.
+// Rows refer to an existing conference by conference_id, or by conference_name when the id is empty.
+// Created conferences with is_archived are archived after their bookings were added. Rows with
+// check-ins or reissued tickets are rejected: the checked-in tickets and the revoked codes would
+// become valid again, as the ledger cannot record them for imported bookings.
+func Import(r io.Reader, format string, opts Options) (Report, error) {
+	rows, rowErrs, err := readRows(r, format)
+	if err != nil {
+		return Report{}, err
+	}
+
+	conferences, err := database.ReadLocalDB()
+	if err != nil {
+		return Report{}, err
+	}
+
+	importer := importer{
+		opts:        opts,
+		report:      Report{DryRun: opts.DryRun, Rows: len(rows) + len(rowErrs), Errors: rowErrs},
+		conferences: conferences,
+		imported:    map[string]*ImportedConference{},
+	}
+	for _, row := range rows {
+		importer.importRow(row)
+	}
+
+	report := importer.report
+	sort.SliceStable(report.Errors, func(a, b int) bool { return report.Errors[a].Line < report.Errors[b].Line })
+	report.Conferences = []ImportedConference{}
+	for _, confId := range importer.order {
+		imported := importer.imported[confId]
+		if imported.archive && !imported.failed {
+			archived := importer.newEvent(model.EventConferenceArchived, confId, time.Now().Format(time.RFC3339))
+			imported.events = append(imported.events, archived)
+		}
+		if len(imported.events) > 0 {
+			report.Conferences = append(report.Conferences, *imported)
+		}
+	}
+	if !report.IsImported() {
+		return report, nil
+	}
+
+	changes := make([][]model.Event, len(report.Conferences))
+	for index, imported := range report.Conferences {
+		changes[index] = imported.events
+	}
+	if len(changes) == 0 {
+		return report, nil
+	}
+	afters, err := database.ApplyConferenceEvents(changes)
+	if err != nil {
+		return report, ApplyError{Err: err}
+	}
+	for index, after := range afters {
+		report.Conferences[index].After = after
+	}
+	return report, nil
+}
+
+type importer struct {
+	opts        Options
+	report      Report
+	conferences []model.Conference
+	imported    map[string]*ImportedConference
+	order       []string
+}
+
+func (i *importer) importRow(row Row) {
+	if len(row.CheckIns) > 0 {
+		i.addError(row, "check_ins", "cannot be imported, the checked-in tickets would become valid again")
+		return
+	}
+	for _, version := range row.TicketVersions {
+		if version > 0 {
+			i.addError(row, "ticket_versions", "cannot be imported, the revoked ticket codes would become valid again")
+			return
+		}
+	}
+
+	errCount := len(i.report.Errors)
+	imported := i.conferenceOf(row)
+	if imported == nil || imported.failed {
+		if imported != nil && len(i.report.Errors) == errCount {
+			i.addError(row, "conference_id", "conference %v of the row is invalid, see the rows before", imported.Id)
+		}
+		return
+	}
+
+	if !row.hasBooking() {
+		return
+	}
+	conference := i.conference(imported.Id)
+
+	req := &model.BookingRequest{CustomerName: row.CustomerName, TicketsBooked: row.TicketsBooked, CustomerEmail: row.CustomerEmail}
+	if req.CustomerName != nil {
+		trimmed := strings.TrimSpace(*req.CustomerName)
+		req.CustomerName = &trimmed
+	}
+	checkedConference := conference
+	if row.IsCanceled && req.TicketsBooked != nil {
+		// a canceled booking holds no tickets, so it also fits into a sold out conference
+		checkedConference.RemainingTickets = *req.TicketsBooked
+	}
+	validationErrs := validation.Booking(req, checkedConference, nil, false)
+	for _, fieldErr := range validationErrs {
+		i.addError(row, fieldErr.Field, "%v", fieldErr.Message)
+	}
+	// booking ids are unique across all conferences
+	isDuplicate := false
+	if otherConference, found := i.conferenceOfBooking(row.BookingId); found {
+		i.addError(row, "booking_id", "booking %v already exists in conference %v", row.BookingId, otherConference)
+		isDuplicate = true
+	}
+
+	bookedAt, ok := i.timestamp(row, "booked_at", row.BookedAt)
+	if !ok || len(validationErrs) > 0 || isDuplicate {
+		return
+	}
+
+	bookingId := row.BookingId
+	if bookingId == "" {
+		bookingId = newId()
+	}
+	created := i.newEvent(model.EventBookingCreated, conference.Id, bookedAt)
+	created.BookingId = bookingId
+	created.CustomerName = *req.CustomerName
+	created.TicketsBooked = *req.TicketsBooked
+	if req.CustomerEmail != nil {
+		created.CustomerEmail = *req.CustomerEmail
+	}
+	events := []model.Event{created}
+
+	if row.IsCanceled {
+		canceledAt, ok := i.timestamp(row, "updated_at", row.UpdatedAt)
+		if !ok {
+			return
+		}
+		if row.UpdatedAt == "" {
+			canceledAt = bookedAt
+		}
+		canceled := i.newEvent(model.EventBookingCanceled, conference.Id, canceledAt)
+		canceled.BookingId = bookingId
+		events = append(events, canceled)
+	}
+
+	if i.apply(row, imported, "booking_id", events...) {
+		imported.Bookings = append(imported.Bookings, bookingId)
+	}
+}
+
+// conferenceOf finds or creates the conference of the row, nil when the row cannot be matched.
+func (i *importer) conferenceOf(row Row) *ImportedConference {
+	row.ConferenceName = strings.TrimSpace(row.ConferenceName)
+
+	var existing *model.Conference
+	for index, conference := range i.conferences {
+		if (row.ConferenceId != "" && conference.Id == row.ConferenceId) ||
+			(row.ConferenceId == "" && conference.ConferenceName == row.ConferenceName) {
+			existing = &i.conferences[index]
+			break
+		}
+	}
+
+	if existing == nil {
+		for _, confId := range i.order {
+			if imported := i.imported[confId]; imported.failed &&
+				(confId == row.ConferenceId || (row.ConferenceId == "" && imported.ConferenceName == row.ConferenceName)) {
+				return imported
+			}
+		}
+		return i.createConference(row)
+	}
+
+	imported, ok := i.imported[existing.Id]
+	if !ok {
+		before := *existing
+		imported = &ImportedConference{Id: existing.Id, ConferenceName: existing.ConferenceName, Before: &before, Bookings: []string{}}
+		i.track(imported)
+	}
+
+	if row.ConferenceName != "" && row.ConferenceName != existing.ConferenceName {
+		i.addError(row, "conference_name", "does not match the name %q of conference %v", existing.ConferenceName, existing.Id)
+		return nil
+	}
+	if row.TotalTickets != nil && *row.TotalTickets != existing.TotalTickets {
+		i.addError(row, "total_tickets", "does not match the %v total tickets of conference %v", existing.TotalTickets, existing.Id)
+		return nil
+	}
+	if row.StartsAt != "" && row.StartsAt != existing.StartsAt {
+		i.addError(row, "starts_at", "does not match the start %q of conference %v", existing.StartsAt, existing.Id)
+		return nil
+	}
+	if isArchived := existing.IsArchived || imported.archive; row.IsArchived != nil && *row.IsArchived != isArchived {
+		i.addError(row, "is_archived", "does not match conference %v, archived is %v", existing.Id, isArchived)
+		return nil
+	}
+	return imported
+}
+
+func (i *importer) createConference(row Row) *ImportedConference {
+	if row.ConferenceId == "" && row.ConferenceName == "" {
+		i.addError(row, "conference_id", "either conference_id or conference_name is required")
+		return nil
+	}
+
+	confId := row.ConferenceId
+	if confId == "" {
+		confId = newId()
+	}
+	imported := &ImportedConference{Id: confId, ConferenceName: row.ConferenceName, Created: true, Bookings: []string{}}
+	i.track(imported)
+
+	req := &model.ConferenceRequest{ConferenceName: &row.ConferenceName, TotalTickets: row.TotalTickets, StartsAt: &row.StartsAt}
+	if row.ConferenceName == "" {
+		req.ConferenceName = nil
+	}
+	validationErrs := validation.Conference(req, nil, i.conferences, false)
+	for _, fieldErr := range validationErrs {
+		i.addError(row, fieldErr.Field, "%v", fieldErr.Message)
+	}
+	createdAt, ok := i.timestamp(row, "booked_at", row.BookedAt)
+	if !ok || len(validationErrs) > 0 {
+		imported.failed = true
+		return nil
+	}
+
+	event := i.newEvent(model.EventConferenceCreated, confId, createdAt)
+	event.ConferenceName = row.ConferenceName
+	event.TotalTickets = *row.TotalTickets
+	event.StartsAt = row.StartsAt
+	if !i.apply(row, imported, "conference_id", event) {
+		imported.failed = true
+		return nil
+	}
+	imported.archive = row.isArchived()
+	return imported
+}
+
+// apply adds the events to the working state of the conference, so later rows are validated against it.
+func (i *importer) apply(row Row, imported *ImportedConference, field string, events ...model.Event) bool {
+	conference := i.conference(imported.Id)
+	var err error
+	for _, event := range events {
+		if conference, err = ledger.Apply(conference, event); err != nil {
+			i.addError(row, field, "%v", err)
+			return false
+		}
+	}
+
+	imported.events = append(imported.events, events...)
+	for index := range i.conferences {
+		if i.conferences[index].Id == conference.Id {
+			i.conferences[index] = conference
+			return true
+		}
+	}
+	i.conferences = append(i.conferences, conference)
+	return true
+}
+
+func (i *importer) conference(confId string) model.Conference {
+	for _, conference := range i.conferences {
+		if conference.Id == confId {
+			return conference
+		}
+	}
+	return model.Conference{}
+}
+
+// conferenceOfBooking returns the id of the conference which has a booking with the id, imported rows included.
+func (i *importer) conferenceOfBooking(bookingId string) (string, bool) {
+	if bookingId == "" {
+		return "", false
+	}
+	for _, conference := range i.conferences {
+		for _, booking := range conference.Bookings {
+			if booking.Id == bookingId {
+				return conference.Id, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (i *importer) track(imported *ImportedConference) {
+	i.imported[imported.Id] = imported
+	i.order = append(i.order, imported.Id)
+}
+
+// timestamp returns the RFC 3339 value of the row field, or the current time when it is empty.
+func (i *importer) timestamp(row Row, field string, value string) (string, bool) {
+	if value == "" {
+		return time.Now().Format(time.RFC3339), true
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		i.addError(row, field, "has to be RFC 3339 timestamp, e.g. 2022-11-02T16:29:20+03:00")
+		return "", false
+	}
+	return value, true
+}
+
+func (i *importer) newEvent(eventType string, confId string, occurredAt string) model.Event {
+	return model.Event{
+		Type:         eventType,
+		OccurredAt:   occurredAt,
+		RequestId:    i.opts.RequestId,
+		ConferenceId: confId,
+	}
+}
+
+func (i *importer) addError(row Row, field string, format string, args ...interface{}) {
+	i.report.Errors = append(i.report.Errors, RowError{Line: row.Line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Export writes the conferences as rows, one per booking, flushing after every conference.
+func Export(w io.Writer, format string, conferences []model.Conference) error {
+	if !IsFormat(format) {
+		return fmt.Errorf("unsupported format %v, use %v or %v", format, FormatCSV, FormatJSONLines)
+	}
+
+	buffered := bufio.NewWriter(w)
+	var writeRow func(row Row) error
+	var flush func() error
+	if format == FormatCSV {
+		csvWriter := csv.NewWriter(buffered)
+		if err := csvWriter.Write(Columns); err != nil {
+			return err
+		}
+		writeRow = func(row Row) error { return csvWriter.Write(row.record()) }
+		flush = func() error {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+			return buffered.Flush()
+		}
+	} else {
+		encoder := json.NewEncoder(buffered)
+		writeRow = func(row Row) error { return encoder.Encode(row) }
+		flush = buffered.Flush
+	}
+
+	for _, conference := range conferences {
+		for _, row := range conferenceRows(conference) {
+			if err := writeRow(row); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+func conferenceRows(conference model.Conference) []Row {
+	totalTickets := conference.TotalTickets
+	confRow := Row{ConferenceId: conference.Id, ConferenceName: conference.ConferenceName, TotalTickets: &totalTickets, StartsAt: conference.StartsAt}
+	if conference.IsArchived {
+		archived := true
+		confRow.IsArchived = &archived
+	}
+	if len(conference.Bookings) == 0 {
+		return []Row{confRow}
+	}
+
+	rows := make([]Row, 0, len(conference.Bookings))
+	for _, booking := range conference.Bookings {
+		row := confRow
+		customerName, ticketsBooked := booking.CustomerName, booking.TicketsBooked
+		row.BookingId = booking.Id
+		row.CustomerName = &customerName
+		if booking.CustomerEmail != "" {
+			customerEmail := booking.CustomerEmail
+			row.CustomerEmail = &customerEmail
+		}
+		row.TicketsBooked = &ticketsBooked
+		row.BookedAt = booking.BookedAt
+		row.UpdatedAt = booking.UpdatedAt
+		row.IsCanceled = booking.IsCanceled
+		for _, checkIn := range booking.CheckIns {
+			row.CheckIns = append(row.CheckIns, checkIn.Ticket)
+		}
+		row.TicketVersions = booking.TicketVersions
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func newId() string {
+	newUuid, _ := uuid.NewRandom()
+	return strings.Replace(newUuid.String(), "-", "", -1)
+}
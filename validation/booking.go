@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"booking-webapp/model"
+)
+
+// Booking validates a booking request for the conference, including the overbooking check.
+// current is nil when a new booking is created, partial skips the fields absent in the request.
+func Booking(req *model.BookingRequest, conference model.Conference, current *model.Booking, partial bool) Errors {
+	errs := validateStruct(req, partial)
+
+	if conference.IsArchived {
+		errs.Add("conference", "conference is archived, bookings cannot be changed")
+	}
+
+	if req.TicketsBooked != nil && *req.TicketsBooked > 0 {
+		availableTickets := conference.RemainingTickets
+		if current != nil && !current.IsCanceled {
+			availableTickets += current.TicketsBooked
+		}
+		if *req.TicketsBooked > availableTickets {
+			errs.Add("tickets_booked", "only %v tickets left for the conference, overbooking is not supported", availableTickets)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"booking-webapp/database"
+	"booking-webapp/model"
+)
+
+// Conference validates a conference request together with the rules which depend on stored data:
+// the name has to be unique and total tickets cannot go below the already booked amount.
+// current is nil when a new conference is created, partial skips the fields absent in the request.
+func Conference(req *model.ConferenceRequest, current *model.Conference, conferences []model.Conference, partial bool) Errors {
+	errs := validateStruct(req, partial)
+
+	if current != nil && current.IsArchived {
+		errs.Add("conference", "conference is archived and cannot be changed")
+	}
+
+	if req.ConferenceName != nil {
+		for _, conference := range conferences {
+			if conference.ConferenceName == *req.ConferenceName && (current == nil || conference.Id != current.Id) {
+				errs.Add("conference_name", "conference name already exist")
+				break
+			}
+		}
+	}
+
+	if req.TotalTickets != nil && current != nil {
+		totalBookings := database.GetTotalBookings(*current)
+		if *req.TotalTickets < totalBookings {
+			errs.Add("total_tickets", "cannot assign %v as total tickets, %v tickets already booked", *req.TotalTickets, totalBookings)
+		}
+	}
+
+	return errs
+}
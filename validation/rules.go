@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"booking-webapp/config"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRules are the configurable limits which can be referenced from `validate` tags.
+// Every rule can be overridden with the VALIDATION_<RULE NAME> env variable,
+// e.g. VALIDATION_MAX_TICKETS_PER_BOOKING=10. Rules ending in _chars are regular expressions,
+// the others are numbers. A rule without a value is not enforced, so bookings are only limited
+// by the remaining tickets unless max_tickets_per_booking is set.
+var defaultRules = map[string]string{
+	"name_min_length":            "2",
+	"conference_name_max_length": "100",
+	"customer_name_max_length":   "100",
+	"customer_email_max_length":  "254",
+	"name_chars":                 `^[\p{L}\p{M}\p{N} .,:'&()/#+_-]+$`,
+	"max_total_tickets":          "100000",
+	"max_tickets_per_booking":    "",
+}
+
+// rule is a parsed rule value, a limit or a pattern.
+type rule struct {
+	value   string
+	limit   int64
+	pattern *regexp.Regexp
+}
+
+func (r rule) isSet() bool {
+	return r.value != ""
+}
+
+var rulesMutex sync.RWMutex
+var rules = mustParseRules(defaultRules)
+
+// isPatternRule tells whether the value of the rule is a regular expression rather than a number.
+func isPatternRule(name string) bool {
+	return strings.HasSuffix(name, "_chars")
+}
+
+func parseRule(value string, isPattern bool) (rule, error) {
+	parsed := rule{value: value}
+	if value == "" {
+		return parsed, nil
+	}
+	if isPattern {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return rule{}, fmt.Errorf("%q is not a valid regular expression", value)
+		}
+		parsed.pattern = pattern
+		return parsed, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return rule{}, fmt.Errorf("%q is not a number", value)
+	}
+	parsed.limit = limit
+	return parsed, nil
+}
+
+func mustParseRules(values map[string]string) map[string]rule {
+	parsed := make(map[string]rule, len(values))
+	for name, value := range values {
+		var err error
+		if parsed[name], err = parseRule(value, isPatternRule(name)); err != nil {
+			panic(fmt.Sprintf("default validation rule %v: %v", name, err))
+		}
+	}
+	return parsed
+}
+
+// LoadRules reads and parses the rules from the configuration, e.g. after config.Load read the config file.
+// The rules are only replaced when all of them are valid, otherwise the problems are returned.
+func LoadRules() error {
+	loaded := make(map[string]rule, len(defaultRules))
+	var problems []string
+	for name, val := range defaultRules {
+		key := "VALIDATION_" + strings.ToUpper(name)
+		parsed, err := parseRule(config.GetEnvOrDefault(key, val), isPatternRule(name))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%v: %v", key, err))
+			continue
+		}
+		loaded[name] = parsed
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid validation rules: %v", strings.Join(problems, ", "))
+	}
+
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	rules = loaded
+	return nil
+}
+
+// SetRule overrides the value of a configurable rule.
+func SetRule(name string, value string) error {
+	parsed, err := parseRule(value, isPatternRule(name))
+	if err != nil {
+		return fmt.Errorf("validation rule %v: %v", name, err)
+	}
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	rules[name] = parsed
+	return nil
+}
+
+// Rule returns the current value of a configurable rule.
+func Rule(name string) string {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	return rules[name].value
+}
+
+// param resolves a tag argument, which is either a rule name or a literal value.
+// Literals are parsed like the rules, an invalid literal is a bug in the tag.
+func param(ruleName string, arg string) rule {
+	rulesMutex.RLock()
+	parsed, ok := rules[arg]
+	rulesMutex.RUnlock()
+	if ok {
+		return parsed
+	}
+
+	parsed, err := parseRule(arg, ruleName == "chars")
+	if err != nil {
+		panic(fmt.Sprintf("validation rule %v: argument %v", ruleName, err))
+	}
+	return parsed
+}
@@ -0,0 +1,181 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes a single violated rule of a request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors collects every violation found in a request so they can be reported at once.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fmt.Sprintf("%v: %v", fieldErr.Field, fieldErr.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *Errors) Add(field string, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Struct checks every field of v that has a `validate` tag.
+// Supported rules are required, omitempty, min, max, minlen, maxlen, chars, fullname, email, datetime and url.
+// omitempty skips the rules after it for an empty value, e.g. an optional field which was cleared.
+// Rule arguments are either literals or names of configurable rules, see SetRule.
+func Struct(v interface{}) Errors {
+	return validateStruct(v, false)
+}
+
+// Partial works like Struct but skips fields which were not supplied (nil pointers),
+// so only the fields present in a partial update are validated.
+func Partial(v interface{}) Errors {
+	return validateStruct(v, true)
+}
+
+func validateStruct(v interface{}, partial bool) Errors {
+	errs := Errors{}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+
+		name := fieldName(field)
+		fieldValue := value.Field(i)
+		isPointer := fieldValue.Kind() == reflect.Ptr
+		if isPointer {
+			if fieldValue.IsNil() {
+				if !partial && hasRule(tag, "required") {
+					errs.Add(name, "is required")
+				}
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			ruleName, arg, _ := strings.Cut(rule, "=")
+			if ruleName == "required" && isPointer {
+				// a supplied pointer field is present even if it holds a zero value
+				continue
+			}
+			if ruleName == "omitempty" {
+				if fieldValue.IsZero() {
+					break
+				}
+				continue
+			}
+			if msg := checkRule(ruleName, arg, fieldValue); msg != "" {
+				errs.Add(name, msg)
+			}
+		}
+	}
+
+	return errs
+}
+
+func checkRule(ruleName string, arg string, value reflect.Value) string {
+	switch ruleName {
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+	case "min":
+		if limit := param(ruleName, arg); limit.isSet() && numberOf(value) < limit.limit {
+			return fmt.Sprintf("must be at least %v", limit.limit)
+		}
+	case "max":
+		if limit := param(ruleName, arg); limit.isSet() && numberOf(value) > limit.limit {
+			return fmt.Sprintf("must be at most %v", limit.limit)
+		}
+	case "minlen":
+		if limit := param(ruleName, arg); limit.isSet() && int64(utf8.RuneCountInString(value.String())) < limit.limit {
+			return fmt.Sprintf("is too short, at least %v characters expected", limit.limit)
+		}
+	case "maxlen":
+		if limit := param(ruleName, arg); limit.isSet() && int64(utf8.RuneCountInString(value.String())) > limit.limit {
+			return fmt.Sprintf("is too long, at most %v characters allowed", limit.limit)
+		}
+	case "chars":
+		if pattern := param(ruleName, arg); pattern.isSet() && value.String() != "" && !pattern.pattern.MatchString(value.String()) {
+			return "contains characters that are not allowed"
+		}
+	case "fullname":
+		if len(strings.Fields(value.String())) < 2 {
+			return "last name is missing, try format 'firstName LastName'"
+		}
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "is not a valid email address, try format 'name@example.com'"
+		}
+	case "datetime":
+		if _, err := time.Parse(time.RFC3339, value.String()); err != nil {
+			return "is not a valid date and time, try format '2006-01-02T15:04:05+01:00'"
+		}
+	case "url":
+		parsed, err := url.Parse(value.String())
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "is not a valid http or https URL"
+		}
+	default:
+		panic(fmt.Sprintf("unknown validation rule %q", ruleName))
+	}
+	return ""
+}
+
+// Removable reports whether the field of v with the given JSON name has the omitempty rule,
+// so a patch may remove it by setting it to its empty value.
+func Removable(v interface{}, name string) bool {
+	valueType := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		if field := valueType.Field(i); fieldName(field) == name {
+			return hasRule(field.Tag.Get("validate"), "omitempty")
+		}
+	}
+	return false
+}
+
+func hasRule(tag string, ruleName string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == ruleName {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(field reflect.StructField) string {
+	jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if jsonName == "" || jsonName == "-" {
+		return field.Name
+	}
+	return jsonName
+}
+
+func numberOf(value reflect.Value) int64 {
+	switch value.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	}
+	panic(fmt.Sprintf("numeric validation rule used on %v field", value.Kind()))
+}
@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"booking-webapp/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructReportsAllViolations(t *testing.T) {
+	name := "R"
+	tickets := uint(0)
+	errs := Struct(&model.BookingRequest{CustomerName: &name, TicketsBooked: &tickets})
+
+	assert.ElementsMatch(t, Errors{
+		{Field: "customer_name", Message: "is too short, at least 2 characters expected"},
+		{Field: "customer_name", Message: "last name is missing, try format 'firstName LastName'"},
+		{Field: "tickets_booked", Message: "must be at least 1"},
+	}, errs)
+}
+
+func TestRequiredAndPartial(t *testing.T) {
+	errs := Struct(&model.ConferenceRequest{})
+	assert.Len(t, errs, 2)
+
+	assert.Empty(t, Partial(&model.ConferenceRequest{}))
+}
+
+func TestConfigurableRules(t *testing.T) {
+	// no limit per booking unless configured
+	name := "Roman Bauer"
+	tickets := uint(1000)
+	assert.Empty(t, Struct(&model.BookingRequest{CustomerName: &name, TicketsBooked: &tickets}))
+
+	defer SetRule("max_tickets_per_booking", Rule("max_tickets_per_booking"))
+	assert.NoError(t, SetRule("max_tickets_per_booking", "5"))
+
+	tickets = 6
+	errs := Struct(&model.BookingRequest{CustomerName: &name, TicketsBooked: &tickets})
+	assert.Equal(t, Errors{{Field: "tickets_booked", Message: "must be at most 5"}}, errs)
+
+	name = "Roman <Bauer>"
+	tickets = 5
+	errs = Struct(&model.BookingRequest{CustomerName: &name, TicketsBooked: &tickets})
+	assert.Equal(t, Errors{{Field: "customer_name", Message: "contains characters that are not allowed"}}, errs)
+}
+
+func TestInvalidRulesAreRejected(t *testing.T) {
+	assert.EqualError(t, SetRule("max_tickets_per_booking", "abc"), `validation rule max_tickets_per_booking: "abc" is not a number`)
+	assert.EqualError(t, SetRule("name_chars", "[a-z"), `validation rule name_chars: "[a-z" is not a valid regular expression`)
+
+	t.Setenv("VALIDATION_MAX_TICKETS_PER_BOOKING", "abc")
+	t.Setenv("VALIDATION_NAME_CHARS", "[a-z")
+	assert.EqualError(t, LoadRules(), `invalid validation rules: VALIDATION_MAX_TICKETS_PER_BOOKING: "abc" is not a number, VALIDATION_NAME_CHARS: "[a-z" is not a valid regular expression`)
+
+	// the rules in use are kept
+	assert.Equal(t, "", Rule("max_tickets_per_booking"))
+	name := "Roman Bauer"
+	assert.Empty(t, Partial(&model.BookingRequest{CustomerName: &name}))
+}
+
+func TestBookingOverbooking(t *testing.T) {
+	conference := model.Conference{TotalTickets: 10, RemainingTickets: 2}
+	current := model.Booking{TicketsBooked: 8}
+	name := "Roman Bauer"
+	tickets := uint(9)
+
+	assert.Empty(t, Booking(&model.BookingRequest{CustomerName: &name, TicketsBooked: &tickets}, conference, &current, false))
+
+	tickets = 11
+	errs := Booking(&model.BookingRequest{TicketsBooked: &tickets}, conference, &current, true)
+	assert.Equal(t, Errors{{Field: "tickets_booked", Message: "only 10 tickets left for the conference, overbooking is not supported"}}, errs)
+}
@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"booking-webapp/model"
+)
+
+// Webhook validates a webhook request, every event type has to be known. partial skips the absent fields.
+func Webhook(req *model.WebhookRequest, partial bool) Errors {
+	errs := validateStruct(req, partial)
+
+	if req.Events == nil {
+		if !partial {
+			errs.Add("events", "is required")
+		}
+		return errs
+	}
+	if len(*req.Events) == 0 {
+		errs.Add("events", "at least one event type or \"*\" is required")
+	}
+	for _, eventType := range *req.Events {
+		if !isWebhookEvent(eventType) {
+			errs.Add("events", "unknown event type %q", eventType)
+		}
+	}
+	return errs
+}
+
+func isWebhookEvent(eventType string) bool {
+	if eventType == model.WebhookAllEvents {
+		return true
+	}
+	for _, known := range model.WebhookEvents {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Package webhooks posts booking and conference events to the URLs of the webhook subscriptions.
+// Every request is signed with the secret of the subscription, see Sign.
+package webhooks
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/logging"
+	"booking-webapp/model"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	IdHeader        = "X-Webhook-Id"
+)
+
+// Event is the body posted to a webhook. Id stays the same when a delivery is retried,
+// so receivers can skip events they already processed.
+type Event struct {
+	Id         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt string          `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// BookingData is the data of the booking events, Previous is the booking before an update.
+type BookingData struct {
+	ConferenceId   string         `json:"conference_id"`
+	ConferenceName string         `json:"conference_name"`
+	Booking        model.Booking  `json:"booking"`
+	Previous       *model.Booking `json:"previous,omitempty"`
+}
+
+// ConferenceData is the data of the conference events, the bookings are left out.
+type ConferenceData struct {
+	Conference model.Conference `json:"conference"`
+}
+
+// NewEvent returns an event with the data encoded as JSON.
+func NewEvent(id string, eventType string, occurredAt string, data interface{}) (Event, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Id: id, Type: eventType, OccurredAt: occurredAt, Data: dataBytes}, nil
+}
+
+// Sign returns the signature header value "t=<unix time>,v1=<hex HMAC-SHA256>". The HMAC is computed
+// with the secret over the timestamp and the body joined by a dot, receivers should recompute it
+// and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unixTime := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unixTime + "."))
+	mac.Write(body)
+	return "t=" + unixTime + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random secret for a webhook.
+func NewSecret() string {
+	first, _ := uuid.NewRandom()
+	second, _ := uuid.NewRandom()
+	return "whsec_" + strings.Replace(first.String()+second.String(), "-", "", -1)
+}
+
+// Send posts the event to the webhook and records the attempt in the delivery log.
+// Responses other than 2xx are returned as error.
+func Send(webhook model.Webhook, event Event) (model.WebhookDelivery, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	deliveryUuid, _ := uuid.NewRandom()
+	startedAt := time.Now()
+	delivery := model.WebhookDelivery{
+		Id:          strings.Replace(deliveryUuid.String(), "-", "", -1),
+		WebhookId:   webhook.Id,
+		Event:       event.Type,
+		EventId:     event.Id,
+		Url:         webhook.Url,
+		DeliveredAt: startedAt.Format(time.RFC3339),
+	}
+
+	sendErr := post(webhook, event, body, startedAt, &delivery)
+	delivery.DurationMs = time.Since(startedAt).Milliseconds()
+	delivery.Success = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := database.AppendWebhookDelivery(delivery); err != nil {
+		logging.Default.Error("cannot append webhook delivery", "event_id", event.Id, "webhook_id", webhook.Id, "error", err)
+	}
+	return delivery, sendErr
+}
+
+func post(webhook model.Webhook, event Event, body []byte, sentAt time.Time, delivery *model.WebhookDelivery) error {
+	timeout := config.Current.Webhooks.Timeout
+
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "booking-webapp-webhooks/1")
+	req.Header.Set(IdHeader, event.Id)
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, sentAt, body))
+
+	res, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	delivery.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %v", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"booking-webapp/config"
+	"booking-webapp/database"
+	"booking-webapp/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", time.Unix(1672653600, 0), []byte(`{"id":"evt_1"}`))
+	assert.Equal(t, "t=1672653600,v1=0e9a68889b0b3a29a76da8498a40d5982f25f347aee35534520dc90bae9832bd", signature)
+}
+
+func TestSend(t *testing.T) {
+	prevPath := config.WEBHOOK_DELIVERY_LOG_PATH
+	config.WEBHOOK_DELIVERY_LOG_PATH = filepath.Join(t.TempDir(), "deliveries.jsonl")
+	t.Cleanup(func() { config.WEBHOOK_DELIVERY_LOG_PATH = prevPath })
+
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _, _ := strings.Cut(strings.TrimPrefix(r.Header.Get(SignatureHeader), "t="), ",")
+		sentAt, _ := strconv.ParseInt(timestamp, 10, 64)
+		assert.Equal(t, Sign("secret", time.Unix(sentAt, 0), body), r.Header.Get(SignatureHeader))
+		assert.Equal(t, "evt_1", r.Header.Get(IdHeader))
+		assert.Equal(t, model.WebhookPing, r.Header.Get(EventHeader))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := model.Webhook{Id: "hook1", Url: server.URL, Secret: "secret"}
+	event, err := NewEvent("evt_1", model.WebhookPing, "2023-01-02T10:00:00Z", map[string]string{"webhook_id": "hook1"})
+	assert.NoError(t, err)
+
+	delivery, err := Send(webhook, event)
+	assert.NoError(t, err)
+	assert.True(t, delivery.Success)
+	assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+
+	status = http.StatusServiceUnavailable
+	delivery, err = Send(webhook, event)
+	assert.EqualError(t, err, "webhook responded with status 503")
+	assert.False(t, delivery.Success)
+
+	deliveries, err := database.ReadWebhookDeliveries("hook1", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].StatusCode, "newest first")
+	}
+}
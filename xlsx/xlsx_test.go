@@ -0,0 +1,36 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	var out bytes.Buffer
+	err := Write(&out, "Boston: 2023/attendees with a very long name", [][]interface{}{
+		{"Customer name", "Tickets"},
+		{"Roman <Bauer> & Co", uint(40)},
+	})
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, _ := file.Open()
+		content, _ := io.ReadAll(reader)
+		parts[file.Name] = string(content)
+	}
+
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts["xl/workbook.xml"], `name="Boston- 2023-attendees with a v"`)
+	assert.Contains(t, parts["xl/worksheets/sheet1.xml"], `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Roman &lt;Bauer&gt; &amp; Co</t></is></c>`)
+	assert.Contains(t, parts["xl/worksheets/sheet1.xml"], `<c r="B2"><v>40</v></c>`)
+	assert.Equal(t, "AB", columnName(27))
+}